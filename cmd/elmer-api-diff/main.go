@@ -0,0 +1,65 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/cmdgen"
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/elmgen"
+)
+
+var (
+	before = flag.String("before", "",
+		"Path to a FileDescriptorSet of the schema before a change.")
+	after = flag.String("after", "",
+		"Path to a FileDescriptorSet of the schema after a change.")
+	ignoreAdditions = flag.Bool("ignore-additions", false,
+		"Don't fail on added fields and variants. These only break record constructors and exhaustive case expressions.")
+)
+
+func main() {
+	flag.Parse()
+	if *before == "" || *after == "" {
+		log.Fatalf("missing -before or -after, see -help")
+	}
+	was, err := cmdgen.ReadModules(*before, "")
+	if err != nil {
+		log.Fatalf("error loading descriptors: %s", err)
+	}
+	is, err := cmdgen.ReadModules(*after, "")
+	if err != nil {
+		log.Fatalf("error loading descriptors: %s", err)
+	}
+
+	var elm, wire []*elmgen.APIChange
+	for _, c := range elmgen.DiffModules(was, is) {
+		if c.Wire {
+			wire = append(wire, c)
+		} else if !c.Addition || !*ignoreAdditions {
+			elm = append(elm, c)
+		}
+	}
+	report := func(title string, changes []*elmgen.APIChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Println(title)
+		for _, c := range changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+	report("Elm API breaking changes:", elm)
+	report("Wire breaking changes:", wire)
+	if len(elm) > 0 || len(wire) > 0 {
+		os.Exit(1)
+	}
+}
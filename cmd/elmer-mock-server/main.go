@@ -0,0 +1,76 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/cmdgen"
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/mockserver"
+	"github.com/rs/cors"
+)
+
+var (
+	descriptors = flag.String("descriptors", "",
+		"Path to a FileDescriptorSet e.g., from protoc --include_imports --descriptor_set_out=api.binpb")
+	addr = flag.String("addr", "localhost:8080",
+		"Address to listen for RPC requests on.")
+	fill = flag.String("fill", "empty",
+		"How to fill responses without a fixture: empty or random.")
+	fixtures = flag.String("fixtures", "",
+		"Directory of <package.Service>/<Method>.textproto responses.")
+	seed = flag.Int64("seed", time.Now().UnixNano(),
+		"Seed for random responses.")
+)
+
+func main() {
+	flag.Parse()
+	if *descriptors == "" {
+		log.Fatalf("missing -descriptors, see -help")
+	}
+	files, err := cmdgen.ReadDescriptorSet(*descriptors)
+	if err != nil {
+		log.Fatalf("error loading descriptors: %s", err)
+	}
+	mode, err := mockserver.ParseFill(*fill)
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	server := mockserver.New(files, mode, *fixtures, *seed)
+	routes := server.Routes()
+	sort.Strings(routes)
+	for _, route := range routes {
+		log.Printf("Serving %s", route)
+	}
+
+	// Allow CORS (net/http wrapper). Same as examples/end-to-end
+	handler := cors.New(cors.Options{
+		AllowOriginFunc:  func(string) bool { return true },
+		AllowCredentials: true,
+		AllowedMethods:   []string{"POST"},
+		AllowedHeaders:   []string{"Content-Type"}}).
+		Handler(logRequests(server))
+
+	// Listen for requests
+	log.Printf("Listening for RPC requests on http://%s", *addr)
+	err = http.ListenAndServe(*addr, handler)
+	if err != nil {
+		log.Fatalf("error listening to RPC server: %s\n", err)
+	}
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("Request on `%s`\n", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,22 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package main
+
+import (
+	"flag"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/cmdgen"
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/elmgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func main() {
+	opts := protogen.Options{
+		ParamFunc: flag.CommandLine.Set}
+	opts.Run(cmdgen.RunGenerator("Explorer", elmgen.GenerateExplorer))
+}
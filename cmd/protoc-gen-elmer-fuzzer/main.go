@@ -15,8 +15,23 @@ import (
 	"google.golang.org/protobuf/compiler/protogen"
 )
 
+var (
+	previous = flag.String("previous", "",
+		"Path to a FileDescriptorSet of a previous schema version. Adds tests that messages survive between versions.")
+)
+
 func main() {
 	opts := protogen.Options{
 		ParamFunc: flag.CommandLine.Set}
-	opts.Run(cmdgen.RunGenerator("Tests", elmgen.GenerateFuzzTests))
+	opts.Run(func(plugin *protogen.Plugin) error {
+		var fuzz elmgen.FuzzOptions
+		if *previous != "" {
+			files, err := cmdgen.ReadDescriptorSet(*previous)
+			if err != nil {
+				return err
+			}
+			fuzz.Previous = files
+		}
+		return cmdgen.RunGenerator("Tests", fuzz.Generate)(plugin)
+	})
 }
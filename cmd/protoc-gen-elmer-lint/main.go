@@ -0,0 +1,48 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/elmgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var (
+	werror = flag.Bool("werror", false,
+		"Treat warnings as errors.")
+)
+
+// Reports findings on stderr. Fails if there are errors so protoc does too. Generates no files
+func main() {
+	opts := protogen.Options{
+		ParamFunc: flag.CommandLine.Set}
+	opts.Run(func(plugin *protogen.Plugin) error {
+		plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		var errors int
+		for _, pkg := range elmgen.FilesToPackages(plugin.Files) {
+			if !pkg.Generate {
+				continue
+			}
+			for _, finding := range elmgen.Lint(elmgen.NewModule("", pkg)) {
+				fmt.Fprintln(os.Stderr, finding)
+				if finding.Severity == elmgen.LintError || *werror {
+					errors++
+				}
+			}
+		}
+		if errors > 0 {
+			return fmt.Errorf("%d lint error(s)", errors)
+		}
+		return nil
+	})
+}
@@ -15,8 +15,17 @@ import (
 	"google.golang.org/protobuf/compiler/protogen"
 )
 
+var (
+	metadata = flag.Bool("metadata", false,
+		"RPC results include the HTTP response's metadata e.g., headers.")
+	effects = flag.Bool("effects", false,
+		"Adds an Effect type describing RPC calls as data for testing.")
+)
+
 func main() {
 	opts := protogen.Options{
 		ParamFunc: flag.CommandLine.Set}
-	opts.Run(cmdgen.RunGenerator("Twirp", elmgen.GenerateTwirp))
+	opts.Run(cmdgen.RunGenerator("Twirp", func(m *elmgen.Module, g *protogen.GeneratedFile) bool {
+		return elmgen.TwirpOptions{Metadata: *metadata, Effects: *effects}.Generate(m, g)
+	}))
 }
@@ -15,8 +15,19 @@ import (
 	"google.golang.org/protobuf/compiler/protogen"
 )
 
+var (
+	strict = flag.Bool("strict", false,
+		"Adds strict decoders that reject unknown fields and enum numbers e.g., decodeFooStrict.")
+	setters = flag.Bool("setters", false,
+		"Adds setters, update functions and lenses for record fields plus prisms for oneof variants.")
+	text = flag.Bool("text", false,
+		"Adds text format (textproto) printers and parsers e.g., toTextFoo and fromTextFoo.")
+)
+
 func main() {
 	opts := protogen.Options{
 		ParamFunc: flag.CommandLine.Set}
-	opts.Run(cmdgen.RunGenerator("", elmgen.GenerateCodec))
+	opts.Run(cmdgen.RunGenerator("", func(m *elmgen.Module, g *protogen.GeneratedFile) bool {
+		return elmgen.CodecOptions{Strict: *strict, Setters: *setters, Text: *text}.Generate(m, g)
+	}))
 }
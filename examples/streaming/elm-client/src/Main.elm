@@ -0,0 +1,143 @@
+module Main exposing (main)
+
+import Browser
+import Gen.Ticker as Ticker
+import Gen.TickerStreams as Streams
+import Html as H exposing (Html)
+import Html.Attributes as HA
+import Html.Events as HE
+import Protobuf.ElmerStreams exposing (Event(..))
+
+
+api : String
+api =
+    "http://localhost:8080/streams"
+
+
+{-| Standard program subscribing to our streams
+-}
+main : Program () Model Msg
+main =
+    Browser.element
+        { init = init
+        , update = update
+        , subscriptions = subscriptions
+        , view = view
+        }
+
+
+type alias Model =
+    -- Most recent countdown tick
+    { remaining : Maybe Int
+
+    -- Is the shout stream open?
+    , shouting : Bool
+
+    -- Form value and what we've heard back
+    , phrase : String
+    , echoes : List String
+
+    -- Last error seen on any stream
+    , error : Maybe String
+    }
+
+
+init : flags -> ( Model, Cmd Msg )
+init _ =
+    ( Model Nothing False "" [] Nothing
+    , Streams.openTicker_Shout api "shout"
+    )
+
+
+type Msg
+    = StartCountdown
+    | CountdownEvent (Event Ticker.Tick)
+    | SetPhrase String
+    | Shout
+    | ShoutEvent (Event Ticker.Phrase)
+
+
+update : Msg -> Model -> ( Model, Cmd Msg )
+update msg model =
+    case msg of
+        StartCountdown ->
+            ( { model | remaining = Nothing }
+            , Streams.openTicker_Countdown api "countdown"
+            )
+
+        CountdownEvent (Opened handle) ->
+            -- Server streaming: our only message starts the countdown
+            ( model
+            , Streams.sendTicker_Countdown handle (Ticker.Start 10 500)
+            )
+
+        CountdownEvent (Received _ tick) ->
+            ( { model | remaining = Just tick.remaining }, Cmd.none )
+
+        CountdownEvent (Closed _) ->
+            ( model, Cmd.none )
+
+        CountdownEvent (Failed _ err) ->
+            ( { model | error = Just err }, Cmd.none )
+
+        SetPhrase str ->
+            ( { model | phrase = str }, Cmd.none )
+
+        Shout ->
+            ( { model | phrase = "" }
+            , Streams.sendTicker_Shout "shout" (Ticker.Phrase model.phrase)
+            )
+
+        ShoutEvent (Opened _) ->
+            ( { model | shouting = True }, Cmd.none )
+
+        ShoutEvent (Received _ phrase) ->
+            ( { model | echoes = phrase.text :: model.echoes }, Cmd.none )
+
+        ShoutEvent (Closed _) ->
+            ( { model | shouting = False }, Cmd.none )
+
+        ShoutEvent (Failed _ err) ->
+            ( { model | error = Just err }, Cmd.none )
+
+
+subscriptions : Model -> Sub Msg
+subscriptions _ =
+    Sub.batch
+        [ Streams.listenTicker_Countdown CountdownEvent
+        , Streams.listenTicker_Shout ShoutEvent
+        ]
+
+
+view : Model -> Html Msg
+view model =
+    H.div []
+        [ H.h1 [] [ H.text "Streams!" ]
+        , case model.error of
+            Just err ->
+                H.p [] [ H.text ("There was an error: " ++ err) ]
+
+            Nothing ->
+                H.text ""
+
+        -- Server streaming
+        , H.h2 [] [ H.text "Countdown" ]
+        , H.p []
+            [ H.text <|
+                case model.remaining of
+                    Just remaining ->
+                        String.fromInt remaining
+
+                    Nothing ->
+                        "Not started"
+            ]
+        , H.button [ HE.onClick StartCountdown ] [ H.text "Start" ]
+
+        -- Bidirectional streaming
+        , H.h2 [] [ H.text "Shout" ]
+        , H.form [ HE.onSubmit Shout ]
+            [ H.input [ HE.onInput SetPhrase, HA.value model.phrase ] []
+            , H.button [ HA.disabled (not model.shouting) ] [ H.text "Shout" ]
+            ]
+        , H.ul [] (List.map (\echo -> H.li [] [ H.text echo ]) model.echoes)
+        ]
@@ -0,0 +1,10 @@
+package streaming
+
+// Go server codegen (protobuf-go only, Twirp doesn't stream)
+//go:generate protoc --go_out=go-server api.proto
+
+// Elm client codegen (output of this project)
+//go:generate protoc --elmer_out=elm-client/src api.proto
+
+// Builds the ports used for streaming RPC methods
+//go:generate protoc --elmer-streams_out=elm-client/src api.proto
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/feral-dot-io/protoc-gen-elmer/examples/streaming/go-server/gen"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	impl := &ticker{}
+	// Each streaming method has its own path, mirroring Twirp's routing
+	mux := http.NewServeMux()
+	mux.Handle("/streams/gen.ticker.Ticker/Countdown", bridge(impl.Countdown))
+	mux.Handle("/streams/gen.ticker.Ticker/Shout", bridge(impl.Shout))
+
+	// Listen for requests
+	log.Printf("Listening for stream requests on ws://localhost:8080/streams")
+	err := http.ListenAndServe("localhost:8080", mux)
+	if err != nil {
+		log.Fatalf("error listening to stream server: %s\n", err)
+	}
+}
+
+// A bidirectional stream of Protobuf messages over a WebSocket
+type stream struct {
+	conn *websocket.Conn
+}
+
+// Handles a single stream. The stream is closed when the handler returns
+type streamHandler func(ctx context.Context, s *stream) error
+
+// We're not doing things like auth. Allow any origin (like CORS in the end-to-end example)
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true }}
+
+// Bridges a WebSocket to a stream handler
+func bridge(handler streamHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("Error upgrading `%s`: %s\n", r.URL.Path, err)
+			return
+		}
+		defer conn.Close()
+		log.Printf("Stream opened on `%s`\n", r.URL.Path)
+
+		err = handler(r.Context(), &stream{conn})
+		if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			log.Printf("Error on `%s`: %s\n", r.URL.Path, err)
+			conn.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+			return
+		}
+		conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	})
+}
+
+// Receives the next message. Frames are binary or base64 encoded text.
+func (s *stream) Recv(msg proto.Message) error {
+	kind, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if kind == websocket.TextMessage {
+		data, err = base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return err
+		}
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// Sends a message as a binary frame
+func (s *stream) Send(msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// Implements our streaming service
+type ticker struct{}
+
+func (t *ticker) Countdown(ctx context.Context, s *stream) error {
+	start := new(pb.Start)
+	if err := s.Recv(start); err != nil {
+		return err
+	}
+	if start.IntervalMs <= 0 {
+		return errors.New("interval must be positive")
+	}
+	tick := time.NewTicker(time.Duration(start.IntervalMs) * time.Millisecond)
+	defer tick.Stop()
+	for remaining := start.From; remaining >= 0; remaining-- {
+		if err := s.Send(&pb.Tick{Remaining: remaining}); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+func (t *ticker) Shout(ctx context.Context, s *stream) error {
+	for {
+		phrase := new(pb.Phrase)
+		if err := s.Recv(phrase); err != nil {
+			return err
+		}
+		phrase.Text = strings.ToUpper(phrase.Text) + "!"
+		if err := s.Send(phrase); err != nil {
+			return err
+		}
+	}
+}
@@ -3,6 +3,7 @@ module github.com/feral-dot-io/protoc-gen-elmer
 go 1.16
 
 require (
+	github.com/gorilla/websocket v1.5.0
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rs/cors v1.8.2
 	github.com/stretchr/testify v1.8.0
@@ -0,0 +1,101 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+
+// Connects the ports of `*Streams.elm` modules generated by `protoc-gen-elmer-streams` to WebSockets. Each streaming method has a pair of ports: one ending in "Out" for frames from Elm and one ending in "In" for frames to Elm. A frame is `{ handle, event, data }` where data is a base64 encoded Protobuf message. Messages are sent over the WebSocket as binary.
+//
+// Usage:
+//   const app = Elm.Main.init({ node: document.getElementById("main") });
+//   connectElmerStreams(app);
+function connectElmerStreams(app) {
+    const ports = app.ports || {};
+    for (const name of Object.keys(ports)) {
+        if (!name.endsWith("Out") || !ports[name].subscribe) {
+            continue;
+        }
+        // Unused ports are removed by the Elm compiler
+        const portIn = ports[name.slice(0, -3) + "In"];
+        const toElm = (handle, event, data) => {
+            if (portIn) {
+                portIn.send({ handle: handle, event: event, data: data || "" });
+            }
+        };
+        const sockets = {};
+        ports[name].subscribe((frame) => {
+            const handle = frame.handle;
+            switch (frame.event) {
+                case "open":
+                    if (sockets[handle]) {
+                        sockets[handle].close();
+                    }
+                    const ws = new WebSocket(toWebSocketURL(frame.data));
+                    ws.binaryType = "arraybuffer";
+                    ws.onopen = () => toElm(handle, "open");
+                    ws.onmessage = (ev) => toElm(handle, "message", toBase64(ev.data));
+                    ws.onerror = () => toElm(handle, "error", "WebSocket error");
+                    ws.onclose = () => {
+                        if (sockets[handle] === ws) {
+                            delete sockets[handle];
+                        }
+                        toElm(handle, "close");
+                    };
+                    sockets[handle] = ws;
+                    break;
+
+                case "send":
+                    if (sockets[handle]) {
+                        sockets[handle].send(fromBase64(frame.data));
+                    } else {
+                        toElm(handle, "error", "stream is not open");
+                    }
+                    break;
+
+                case "close":
+                    if (sockets[handle]) {
+                        sockets[handle].close();
+                    }
+                    break;
+            }
+        });
+    }
+}
+
+// Allows the same API base to be used for Twirp (http) and streams (ws)
+function toWebSocketURL(url) {
+    const resolved = new URL(url, window.location.href);
+    if (resolved.protocol === "http:") {
+        resolved.protocol = "ws:";
+    } else if (resolved.protocol === "https:") {
+        resolved.protocol = "wss:";
+    }
+    return resolved.toString();
+}
+
+function toBase64(data) {
+    if (typeof data === "string") { // Text frames are already base64
+        return data;
+    }
+    const bytes = new Uint8Array(data);
+    let binary = "";
+    for (let i = 0; i < bytes.length; i++) {
+        binary += String.fromCharCode(bytes[i]);
+    }
+    return btoa(binary);
+}
+
+function fromBase64(str) {
+    const binary = atob(str);
+    const bytes = new Uint8Array(binary.length);
+    for (let i = 0; i < binary.length; i++) {
+        bytes[i] = binary.charCodeAt(i);
+    }
+    return bytes;
+}
+
+if (typeof module !== "undefined") {
+    module.exports = { connectElmerStreams };
+}
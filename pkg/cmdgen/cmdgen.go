@@ -22,29 +22,50 @@ var (
 
 type Generator func(*elmgen.Module, *protogen.GeneratedFile) bool
 
+// A generator and the suffix identifying its outputted files
+type Output struct {
+	Suffix    string
+	Generator Generator
+}
+
 // Creates a function that runs the given generator over all of a plugin's files to be generated. Applies options from global flags. The suffix is intended to identify the outputted files from the generator.
 func RunGenerator(suffix string, generator Generator) func(*protogen.Plugin) error {
+	return RunGenerators(Output{suffix, generator})
+}
+
+// Like RunGenerator but outputs a file per generator for each package. Used when generated code depends on another generator's output e.g., REST clients on JSON codecs.
+func RunGenerators(outputs ...Output) func(*protogen.Plugin) error {
 	return func(plugin *protogen.Plugin) error {
 		plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		// Collect every error before reporting
+		var errs elmgen.Errors
 		// Generate a file per PB package
 		for _, pkg := range elmgen.FilesToPackages(plugin.Files) {
 			if !pkg.Generate {
 				continue
 			}
-			// Map Proto to Elm types
-			elm := elmgen.NewModule(suffix, pkg)
-			// Write to file
-			genFile := plugin.NewGeneratedFile(elm.Path, "")
-			valid := generator(elm, genFile)
-			if valid {
-				// Format file?
-				if *format {
-					elmgen.FormatFile(plugin, elm.Path, genFile)
+			for _, out := range outputs {
+				// Map Proto to Elm types
+				elm := elmgen.NewModule(out.Suffix, pkg)
+				// Write to file
+				genFile := plugin.NewGeneratedFile(elm.Path, "")
+				valid := out.Generator(elm, genFile)
+				if elm.Err() != nil {
+					errs = errs.Merge(elm.Errors)
+					genFile.Skip()
+				} else if valid {
+					// Format file?
+					if *format {
+						elmgen.FormatFile(plugin, elm.Path, genFile)
+					}
+				} else {
+					genFile.Skip()
 				}
-			} else {
-				genFile.Skip()
 			}
 		}
+		if len(errs) > 0 {
+			plugin.Error(errs)
+		}
 		return nil
 	}
 }
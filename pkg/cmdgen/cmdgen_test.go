@@ -0,0 +1,65 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package cmdgen
+
+import (
+	"testing"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/elmgen"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestRunGeneratorErrors(t *testing.T) {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum()}
+	}
+	// Source info for message 0's fields: path is [message_type, 0, field, i]
+	location := func(i, line, col int32) *descriptorpb.SourceCodeInfo_Location {
+		return &descriptorpb.SourceCodeInfo_Location{
+			Path: []int32{4, 0, 2, i},
+			Span: []int32{line, col, col + 10}}
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"oops.proto"},
+		Parameter:      proto.String("Moops.proto=example.com/oops"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("oops.proto"),
+			Package: proto.String("test.oops"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Oops"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("ok", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+					field("big", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+					field("id", 3, descriptorpb.FieldDescriptorProto_TYPE_FIXED64)}}},
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+				Location: []*descriptorpb.SourceCodeInfo_Location{
+					location(0, 4, 2), location(1, 5, 2), location(2, 6, 2)}}}}}
+	plugin, err := protogen.Options{}.New(req)
+	assert.NoError(t, err)
+
+	// Errors are collected from every generator without duplicates
+	err = RunGenerators(
+		Output{"", elmgen.GenerateCodec},
+		Output{"Tests", elmgen.GenerateFuzzTests})(plugin)
+	assert.NoError(t, err)
+	resp := plugin.Response()
+	assert.Equal(t, "oops.proto:6:3: test.oops.Oops.big: 64-bit integers (int64) are unsupported by Elm\n"+
+		"oops.proto:7:3: test.oops.Oops.id: 64-bit integers (fixed64) are unsupported by Elm",
+		resp.GetError())
+	assert.Empty(t, resp.File)
+}
@@ -0,0 +1,73 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package cmdgen
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/elmgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Loads a binary `FileDescriptorSet` e.g., from `protoc --include_imports --descriptor_set_out`
+func ReadDescriptorSet(path string) (*protoregistry.Files, error) {
+	set, err := readDescriptorSet(path)
+	if err != nil {
+		return nil, err
+	}
+	return protodesc.NewFiles(set)
+}
+
+// Like ReadDescriptorSet but builds an Elm module for each package as if it were passed to a plugin. Google's packages (e.g., well-known types) are only used as imports.
+func ReadModules(path, suffix string) ([]*elmgen.Module, error) {
+	set, err := readDescriptorSet(path)
+	if err != nil {
+		return nil, err
+	}
+	// Plugins need a Go import path for every file. Any will do
+	var params []string
+	req := &pluginpb.CodeGeneratorRequest{ProtoFile: set.File}
+	for _, f := range set.File {
+		params = append(params, "M"+f.GetName()+"=elmer/"+strings.TrimSuffix(f.GetName(), ".proto"))
+		if !strings.HasPrefix(f.GetPackage(), "google.") {
+			req.FileToGenerate = append(req.FileToGenerate, f.GetName())
+		}
+	}
+	req.Parameter = proto.String(strings.Join(params, ","))
+	plugin, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading descriptor set %s: %w", path, err)
+	}
+
+	var modules []*elmgen.Module
+	for _, pkg := range elmgen.FilesToPackages(plugin.Files) {
+		if pkg.Generate {
+			modules = append(modules, elmgen.NewModule(suffix, pkg))
+		}
+	}
+	return modules, nil
+}
+
+func readDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("reading descriptor set %s: %w", path, err)
+	}
+	return set, nil
+}
@@ -0,0 +1,302 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// A breaking change between two versions of a schema. Elm API changes break code using the generated modules e.g., a renamed field. Wire changes break communication between old and new code e.g., a field number reused with another type.
+type APIChange struct {
+	Wire     bool   // Breaks the wire format instead of the Elm API
+	Addition bool   // Something was added. Breaks exhaustive case expressions and record constructors, see `elm diff`
+	Subject  string // Elm module for API changes, Protobuf ident for wire changes
+	Message  string
+}
+
+func (c *APIChange) String() string {
+	return c.Subject + ": " + c.Message
+}
+
+// Lists breaking changes between two sets of modules e.g., from descriptor sets before and after a change. Modules are matched by name, types and RPCs by their Elm ID. Derived functions (decoders, encoders, etc.) follow their types so aren't listed separately.
+func DiffModules(before, after []*Module) []*APIChange {
+	d := new(apiDiff)
+	index := make(map[string]*Module)
+	for _, m := range after {
+		index[m.Name] = m
+	}
+	for _, old := range before {
+		m := index[old.Name]
+		if m == nil {
+			d.elm(old.Name, "module removed")
+			for _, s := range old.Services {
+				for _, rpc := range s.Methods {
+					d.wire(rpc.Desc.FullName(), "method removed")
+				}
+			}
+			continue
+		}
+		d.unions(old, m)
+		d.records(old, m)
+		d.oneofs(old, m)
+		d.rpcs(old, m)
+	}
+	return d.changes
+}
+
+type apiDiff struct {
+	changes []*APIChange
+}
+
+func (d *apiDiff) elm(subject, formatter string, args ...interface{}) {
+	d.changes = append(d.changes, &APIChange{false, false, subject, fmt.Sprintf(formatter, args...)})
+}
+
+func (d *apiDiff) added(subject, formatter string, args ...interface{}) {
+	d.changes = append(d.changes, &APIChange{false, true, subject, fmt.Sprintf(formatter, args...)})
+}
+
+func (d *apiDiff) wire(subject protoreflect.FullName, formatter string, args ...interface{}) {
+	d.changes = append(d.changes, &APIChange{true, false, string(subject), fmt.Sprintf(formatter, args...)})
+}
+
+func (d *apiDiff) unions(old, m *Module) {
+	index := make(map[string]*Union)
+	for _, u := range m.Unions {
+		index[u.Type.ID] = u
+	}
+	for _, before := range old.Unions {
+		after := index[before.Type.ID]
+		if after == nil {
+			d.elm(old.Name, "union %s removed", before.Type.ID)
+			continue
+		}
+		// Elm API
+		variants := make(map[string]*Variant)
+		for _, v := range after.Variants {
+			variants[v.ID.ID] = v
+		}
+		for _, v := range before.Variants {
+			if variants[v.ID.ID] == nil {
+				d.elm(old.Name, "union %s: variant %s removed", before.Type.ID, v.ID.ID)
+			}
+			delete(variants, v.ID.ID)
+		}
+		for _, v := range after.Variants {
+			if variants[v.ID.ID] != nil {
+				d.added(old.Name, "union %s: variant %s added", before.Type.ID, v.ID.ID)
+			}
+		}
+		aliases := make(map[string]bool)
+		for _, a := range after.Aliases {
+			aliases[a.Alias.ID] = true
+		}
+		for _, a := range before.Aliases {
+			if !aliases[a.Alias.ID] {
+				d.elm(old.Name, "union %s: alias %s removed", before.Type.ID, a.Alias.ID)
+			}
+		}
+		// Wire
+		values := after.Desc.Values()
+		for _, v := range before.Variants {
+			if values.ByNumber(v.Number) == nil && !after.Desc.ReservedRanges().Has(v.Number) {
+				d.wire(before.Desc.FullName(), "value %d removed without reserving its number", v.Number)
+			}
+			if now := values.ByName(protoreflect.Name(v.Label)); now != nil && now.Number() != v.Number {
+				d.wire(before.Desc.FullName(), "value %s changed number from %d to %d", v.Label, v.Number, now.Number())
+			}
+		}
+	}
+}
+
+func (d *apiDiff) records(old, m *Module) {
+	index := make(map[string]*Record)
+	for _, r := range m.Records {
+		index[r.Type.ID] = r
+	}
+	for _, before := range old.Records {
+		after := index[before.Type.ID]
+		if after == nil {
+			d.elm(old.Name, "record %s removed", before.Type.ID)
+			continue
+		}
+		// Elm API
+		fields := make(map[string]*Field)
+		for _, f := range after.Fields {
+			fields[f.Label] = f
+		}
+		for _, f := range before.Fields {
+			now := fields[f.Label]
+			delete(fields, f.Label)
+			if now == nil {
+				d.elm(old.Name, "record %s: field %s removed", before.Type.ID, f.Label)
+			} else if was, is := fieldType(old, f), fieldType(m, now); was != is {
+				d.elm(old.Name, "record %s: field %s changed type from %s to %s", before.Type.ID, f.Label, was, is)
+			}
+		}
+		for _, f := range after.Fields {
+			if fields[f.Label] != nil {
+				d.added(old.Name, "record %s: field %s added", before.Type.ID, f.Label)
+			}
+		}
+		// Wire
+		fds := before.Desc.Fields()
+		for i := 0; i < fds.Len(); i++ {
+			fd := fds.Get(i)
+			now := after.Desc.Fields().ByNumber(fd.Number())
+			if now == nil {
+				if !after.Desc.ReservedRanges().Has(fd.Number()) {
+					d.wire(before.Desc.FullName(), "field %d (%s) removed without reserving its number", fd.Number(), fd.Name())
+				}
+			} else if !wireCompatible(fd, now) {
+				d.wire(before.Desc.FullName(), "field %d (%s) changed from %s to %s", fd.Number(), fd.Name(), wireType(fd), wireType(now))
+			}
+		}
+	}
+}
+
+func (d *apiDiff) oneofs(old, m *Module) {
+	index := make(map[string]*Oneof)
+	for _, o := range m.Oneofs {
+		index[o.Type.ID] = o
+	}
+	for _, before := range old.Oneofs {
+		if before.IsSynthetic { // Part of the record
+			continue
+		}
+		after := index[before.Type.ID]
+		if after == nil {
+			d.elm(old.Name, "oneof %s removed", before.Type.ID)
+			continue
+		}
+		variants := make(map[string]*OneofVariant)
+		for _, v := range after.Variants {
+			variants[v.ID.ID] = v
+		}
+		for _, v := range before.Variants {
+			now := variants[v.ID.ID]
+			delete(variants, v.ID.ID)
+			if now == nil {
+				d.elm(old.Name, "oneof %s: variant %s removed", before.Type.ID, v.ID.ID)
+			} else if was, is := fieldType(old, v.Field), fieldType(m, now.Field); was != is {
+				d.elm(old.Name, "oneof %s: variant %s changed type from %s to %s", before.Type.ID, v.ID.ID, was, is)
+			}
+		}
+		for _, v := range after.Variants {
+			if variants[v.ID.ID] != nil {
+				d.added(old.Name, "oneof %s: variant %s added", before.Type.ID, v.ID.ID)
+			}
+		}
+	}
+}
+
+func (d *apiDiff) rpcs(old, m *Module) {
+	index := make(map[string]*RPC)
+	for _, s := range m.Services {
+		for _, rpc := range s.Methods {
+			index[rpc.ID.ID] = rpc
+		}
+	}
+	for _, s := range old.Services {
+		for _, before := range s.Methods {
+			after := index[before.ID.ID]
+			if after == nil {
+				d.elm(old.Name, "RPC %s removed", before.ID.ID)
+				d.wire(before.Desc.FullName(), "method removed")
+				continue
+			}
+			if was, is := rpcSignature(before), rpcSignature(after); was != is {
+				d.elm(old.Name, "RPC %s changed from %s to %s", before.ID.ID, was, is)
+			}
+			if before.Desc.Input().FullName() != after.Desc.Input().FullName() ||
+				before.Desc.Output().FullName() != after.Desc.Output().FullName() ||
+				before.IsStreaming() != after.IsStreaming() {
+				d.wire(before.Desc.FullName(), "method changed from %s to %s", wireMethod(before.Desc), wireMethod(after.Desc))
+			}
+		}
+	}
+}
+
+// Describes the Elm types of an RPC
+func rpcSignature(rpc *RPC) string {
+	in := "()"
+	if !rpc.InEmpty {
+		in = rpc.In.String()
+	}
+	sig := in + " -> " + rpc.OutType()
+	if rpc.InStreaming {
+		sig = "stream " + sig
+	}
+	if rpc.OutStreaming {
+		sig += " stream"
+	}
+	return sig
+}
+
+// Describes a method in Protobuf syntax
+func wireMethod(md protoreflect.MethodDescriptor) string {
+	stream := func(streaming bool) string {
+		if streaming {
+			return "stream "
+		}
+		return ""
+	}
+	return fmt.Sprintf("(%s%s) returns (%s%s)",
+		stream(md.IsStreamingClient()), md.Input().FullName(),
+		stream(md.IsStreamingServer()), md.Output().FullName())
+}
+
+// Describes a field's type in Protobuf syntax
+func wireType(fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", wireType(fd.MapKey()), wireType(fd.MapValue()))
+	}
+	name := fd.Kind().String()
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		name = string(fd.Enum().FullName())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		name = string(fd.Message().FullName())
+	}
+	if fd.IsList() {
+		return "repeated " + name
+	}
+	return name
+}
+
+// Kinds sharing a wire encoding that can be swapped without breaking the other side
+var wireKinds = map[protoreflect.Kind]int{
+	protoreflect.Int32Kind: 1, protoreflect.Uint32Kind: 1, protoreflect.Int64Kind: 1,
+	protoreflect.Uint64Kind: 1, protoreflect.BoolKind: 1, protoreflect.EnumKind: 1,
+	protoreflect.Sint32Kind: 2, protoreflect.Sint64Kind: 2,
+	protoreflect.Fixed32Kind: 3, protoreflect.Sfixed32Kind: 3,
+	protoreflect.Fixed64Kind: 4, protoreflect.Sfixed64Kind: 4,
+	protoreflect.StringKind: 5, protoreflect.BytesKind: 5,
+}
+
+// Returns true if a field can be read by either version
+func wireCompatible(before, after protoreflect.FieldDescriptor) bool {
+	if before.IsMap() || after.IsMap() {
+		return before.IsMap() && after.IsMap() &&
+			wireCompatible(before.MapKey(), after.MapKey()) &&
+			wireCompatible(before.MapValue(), after.MapValue())
+	}
+	if before.IsList() != after.IsList() {
+		return false
+	}
+	if before.Kind() == after.Kind() {
+		if before.Message() != nil { // Enums are all varints
+			return before.Message().FullName() == after.Message().FullName()
+		}
+		return true
+	}
+	group := wireKinds[before.Kind()]
+	return group != 0 && group == wireKinds[after.Kind()]
+}
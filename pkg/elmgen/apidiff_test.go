@@ -0,0 +1,96 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffModules(t *testing.T) {
+	modules := func(spec string) []*Module {
+		var out []*Module
+		for _, pkg := range FilesToPackages(testPlugin(t, spec).Files) {
+			if pkg.Generate {
+				out = append(out, NewModule("", pkg))
+			}
+		}
+		return out
+	}
+	before := modules(`
+		syntax = "proto3";
+		package test.diff;
+		enum Colour { RED = 0; GREEN = 1; BLUE = 2; }
+		message Msg {
+			int32 a = 1;
+			string name = 2;
+			int32 changed = 3;
+			uint32 gone = 4;
+			int32 compatible = 5;
+			oneof choice { string x = 6; }
+		}
+		message Req {}
+		service Svc {
+			rpc Get(Req) returns (Msg);
+			rpc Del(Req) returns (Req);
+		}
+	`)
+	after := modules(`
+		syntax = "proto3";
+		package test.diff;
+		enum Colour { RED = 0; VERDE = 1; }
+		message Msg {
+			reserved 4;
+			int32 a = 1;
+			string renamed = 2;
+			float changed = 3;
+			uint32 compatible = 5;
+			oneof choice { string x = 6; int32 y = 7; }
+		}
+		message Req {}
+		service Svc {
+			rpc Get(Req) returns (stream Msg);
+		}
+	`)
+
+	var elm, added, wire []string
+	for _, c := range DiffModules(before, after) {
+		switch {
+		case c.Wire:
+			wire = append(wire, c.String())
+		case c.Addition:
+			added = append(added, c.String())
+		default:
+			elm = append(elm, c.String())
+		}
+	}
+	assert.Equal(t, []string{
+		"Test.Diff: union Colour: variant Green removed",
+		"Test.Diff: union Colour: variant Blue removed",
+		"Test.Diff: record Msg: field name removed",
+		"Test.Diff: record Msg: field changed changed type from Int to Float",
+		"Test.Diff: record Msg: field gone removed",
+		"Test.Diff: RPC twirpSvc_Del removed",
+		"Test.Diff: RPC twirpSvc_Get changed from Req -> Msg to Req -> Msg stream",
+	}, elm)
+	assert.Equal(t, []string{
+		"Test.Diff: union Colour: variant Verde added",
+		"Test.Diff: record Msg: field renamed added",
+		"Test.Diff: oneof Msg_Choice: variant Msg_Y added",
+	}, added)
+	assert.Equal(t, []string{
+		"test.diff.Colour: value 2 removed without reserving its number",
+		"test.diff.Msg: field 3 (changed) changed from int32 to float",
+		"test.diff.Svc.Del: method removed",
+		"test.diff.Svc.Get: method changed from (test.diff.Req) returns (test.diff.Msg) to (test.diff.Req) returns (stream test.diff.Msg)",
+	}, wire)
+
+	// No changes
+	assert.Empty(t, DiffModules(after, after))
+}
@@ -0,0 +1,458 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictDecoders(t *testing.T) {
+	testElmFiles = map[string]string{"Test/StrictDecodingTests.elm": `
+module Test.StrictDecodingTests exposing (suite)
+
+import Dict
+import Expect
+import Protobuf.Decode as PD
+import Protobuf.Encode as PE
+import Test exposing (Test, describe, test)
+import Test.Strictness as S
+import Test.Strictness.Legacy as L
+
+
+rejects : String -> PD.Decoder (Maybe a) -> PD.Decoder a -> PE.Encoder -> Test
+rejects name strict lenient encoder =
+    let
+        bytes =
+            PE.encode encoder
+    in
+    describe name
+        [ test "strict" <| \_ -> PD.decode strict bytes |> Expect.equal (Just Nothing)
+        , test "lenient" <| \_ -> PD.decode lenient bytes |> Expect.notEqual Nothing
+        ]
+
+
+suite : Test
+suite =
+    let
+        paint =
+            { colour = S.Blue, layers = [ S.Red, S.ColourUnspecified ] }
+    in
+    describe "strict decoders"
+        [ test "accept known fields" <|
+            \_ ->
+                PD.decode S.decodeCanvasStrict (PE.encode (S.encodeCanvas (S.Canvas paint (Dict.singleton "a" paint))))
+                    |> Expect.equal (Just (Just (S.Canvas paint (Dict.singleton "a" paint))))
+        , test "accept closed enums" <|
+            \_ ->
+                PD.decode L.decodeShirtStrict (PE.encode (PE.message [ ( 1, PE.int32 2 ) ]))
+                    |> Expect.equal (Just (Just { size = L.Large }))
+        , rejects "unknown enum" S.decodePaintStrict S.decodePaint <|
+            PE.message [ ( 1, PE.int32 7 ) ]
+        , rejects "unknown packed enum" S.decodePaintStrict S.decodePaint <|
+            PE.message [ ( 2, PE.list PE.int32 [ 1, 7 ] ) ]
+        , rejects "unknown field" S.decodePaintStrict S.decodePaint <|
+            PE.message [ ( 9, PE.string "?" ) ]
+        , rejects "unknown nested field" S.decodeCanvasStrict S.decodeCanvas <|
+            PE.message [ ( 1, PE.message [ ( 9, PE.string "?" ) ] ) ]
+        , rejects "unknown enum in map" S.decodeCanvasStrict S.decodeCanvas <|
+            PE.message [ ( 2, PE.message [ ( 1, PE.string "a" ), ( 2, PE.message [ ( 1, PE.int32 7 ) ] ) ] ) ]
+        , rejects "closed enum violation" L.decodeShirtStrict L.decodeShirt <|
+            PE.message [ ( 1, PE.int32 3 ) ]
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.strictness;
+		enum Colour {
+			COLOUR_UNSPECIFIED = 0;
+			RED = 1;
+			BLUE = -2;
+		}
+		message Paint {
+			Colour colour = 1;
+			repeated Colour layers = 2;
+		}
+		message Canvas {
+			Paint paint = 1;
+			map<string, Paint> named = 2;
+		}`, `
+		syntax = "proto2";
+		package test.strictness.legacy;
+		enum Size {
+			SMALL = 1;
+			LARGE = 2;
+		}
+		message Shirt {
+			optional Size size = 1;
+		}`)
+	assert.Contains(t, string(testFileContents["Test/Strictness.elm"]),
+		"decodeCanvasStrict : PD.Decoder (Maybe Canvas)")
+}
+
+func TestSetters(t *testing.T) {
+	testElmFiles = map[string]string{"Test/SettersUsageTests.elm": `
+module Test.SettersUsageTests exposing (suite)
+
+import Expect
+import Protobuf.Elmer as Elmer
+import Test exposing (Test, describe, test)
+import Test.Setters as S
+
+
+suite : Test
+suite =
+    let
+        canvas =
+            S.emptyCanvas
+
+        colour =
+            Elmer.composeLens S.lensCanvasPaint S.lensPaintColour
+
+        picked =
+            Elmer.composePrism S.lensCanvasPick S.prismCanvas_B
+    in
+    describe "setters"
+        [ test "set" <|
+            \_ -> (S.setCanvasS (Just "s") canvas).s |> Expect.equal (Just "s")
+        , test "update" <|
+            \_ -> (S.updateCanvasInner (S.setCanvas_InnerOn True) canvas).inner.on |> Expect.equal True
+        , test "nested lens" <|
+            \_ -> Elmer.modify colour ((+) 2) canvas |> colour.get |> Expect.equal 2
+        , test "prism misses" <|
+            \_ -> picked.getOption (S.setCanvasPick (Just (S.Canvas_A 1)) canvas) |> Expect.equal Nothing
+        , test "prism sets" <|
+            \_ ->
+                picked.set (S.Paint 3) canvas
+                    |> .pick
+                    |> Expect.equal (Just (S.Canvas_B (S.Paint 3)))
+        , test "prism modifies" <|
+            \_ ->
+                picked.set (S.Paint 3) canvas
+                    |> Elmer.modifyOptional (Elmer.composeOptional picked S.lensPaintColour) ((+) 1)
+                    |> picked.getOption
+                    |> Expect.equal (Just (S.Paint 4))
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.setters;
+		message Paint { int32 colour = 1; }
+		message Canvas {
+			Paint paint = 1;
+			map<string, Paint> named = 2;
+			oneof pick { int32 a = 3; Paint b = 4; }
+			optional string s = 5;
+			message Inner { bool on = 1; }
+			Inner inner = 6;
+		}`)
+	assert.Len(t, elm.Records, 3)
+	content := string(testFileContents["Test/Setters.elm"])
+	assert.Contains(t, content, "updatePaintColour : (Int -> Int) -> Paint -> Paint")
+	assert.Contains(t, content, "prismCanvas_A : Protobuf.Elmer.Prism (Maybe Canvas_Pick) Int")
+}
+
+func TestOneofHelpers(t *testing.T) {
+	testElmFiles = map[string]string{"Test/OneofUsageTests.elm": `
+module Test.OneofUsageTests exposing (suite)
+
+import Expect
+import Test exposing (Test, describe, test)
+import Test.Oneofs as O
+
+
+suite : Test
+suite =
+    let
+        named =
+            O.setShape_Name "square" O.emptyShape
+    in
+    describe "oneof helpers"
+        [ test "is" <|
+            \_ -> ( O.isShape_Name named, O.isShape_Sides named ) |> Expect.equal ( True, False )
+        , test "get" <|
+            \_ -> ( O.getShape_Name named, O.getShape_Sides named ) |> Expect.equal ( Just "square", Nothing )
+        , test "set replaces" <|
+            \_ -> O.setShape_Sides 4 named |> O.getShape_Name |> Expect.equal Nothing
+        , test "case" <|
+            \_ -> ( O.caseShape_Kind named, O.caseShape_Kind O.emptyShape ) |> Expect.equal ( Just "name", Nothing )
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.oneofs;
+		message Shape {
+			oneof kind {
+				string name = 1;
+				int32 sides = 2;
+			}
+			optional int32 ignored = 3;
+		}`)
+	content := string(testFileContents["Test/Oneofs.elm"])
+	assert.Contains(t, content, "getShape_Sides : Shape -> Maybe Int")
+	assert.Contains(t, content, "caseShape_Kind : Shape -> Maybe String")
+	assert.NotContains(t, content, "isShape_Ignored")
+}
+
+func TestEquality(t *testing.T) {
+	testElmFiles = map[string]string{"Test/EqualityUsageTests.elm": `
+module Test.EqualityUsageTests exposing (suite)
+
+import Bytes.Encode as BE
+import Dict
+import Expect
+import Test exposing (Test, describe, test)
+import Test.Equality as E
+
+
+suite : Test
+suite =
+    let
+        bytes s =
+            BE.encode (BE.string s)
+
+        blob s =
+            { data = bytes s
+            , chunks = [ bytes s ]
+            , named = Dict.singleton s (bytes s)
+            , flagged = [ ( True, bytes s ) ]
+            , pick = Just (E.Blob_Raw (bytes s))
+            , xmaybe = Just (bytes s)
+            , single = Just (E.Blob_Only s)
+            }
+    in
+    describe "equality"
+        [ test "equal bytes" <|
+            \_ -> E.equalBlob (blob "a") (blob "a") |> Expect.equal True
+        , test "different bytes" <|
+            \_ -> E.equalBlob (blob "a") (blob "b") |> Expect.equal False
+        , test "different variants" <|
+            \_ -> E.equalBlob_Pick (E.Blob_Raw (bytes "a")) (E.Blob_Colour E.Red) |> Expect.equal False
+        , test "nested" <|
+            \_ -> E.equalHolder { blob = blob "a", blobs = [ blob "a" ] } { blob = blob "a", blobs = [ blob "b" ] } |> Expect.equal False
+        , test "unions" <|
+            \_ -> E.equalColour E.Red E.Red |> Expect.equal True
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.equality;
+		import "google/protobuf/any.proto";
+		import "google/protobuf/wrappers.proto";
+		enum Colour { COLOUR_UNSPECIFIED = 0; RED = 1; }
+		message Blob {
+			bytes data = 1;
+			repeated bytes chunks = 2;
+			map<string, bytes> named = 3;
+			map<bool, bytes> flagged = 4;
+			oneof pick { bytes raw = 5; Colour colour = 6; }
+			optional bytes maybe = 7;
+			oneof single { string only = 8; }
+		}
+		message Holder {
+			Blob blob = 1;
+			repeated Blob blobs = 2;
+		}
+		message Wrapped {
+			google.protobuf.Any any = 1;
+			google.protobuf.BytesValue value = 2;
+		}`)
+	content := string(testFileContents["Test/Equality.elm"])
+	assert.Contains(t, content, "equalHolder : Holder -> Holder -> Bool")
+	assert.Contains(t, content, "Protobuf.Elmer.equalEncoded Protobuf.Elmer.encodeBytesValue")
+	assert.Contains(t, content, "equalWrapped : Wrapped -> Wrapped -> Bool")
+	// Any has no JSON mapping so only the JSON codec skips it
+	json := string(testFileContents["Test/EqualityJson.elm"])
+	assert.Contains(t, json, "-- Skipped test.equality.Wrapped: well-known type google.protobuf.Any has no JSON codec")
+	assert.Contains(t, json, "decodeHolder : JD.Decoder Test.Equality.Holder")
+}
+
+func TestMerge(t *testing.T) {
+	testModule(t, `
+		syntax = "proto3";
+		package test.merging;
+		import "google/protobuf/timestamp.proto";
+		enum Colour { COLOUR_UNSPECIFIED = 0; RED = 1; }
+		message Sub { int32 n = 1; repeated string tags = 2; }
+		message Layered {
+			int32 count = 1;
+			bytes data = 2;
+			Colour colour = 3;
+			Sub sub = 4;
+			repeated Sub subs = 5;
+			map<string, Sub> named = 6;
+			map<bool, int32> flags = 7;
+			oneof pick { int32 a = 8; Sub b = 9; }
+			optional string s = 10;
+			google.protobuf.Timestamp at = 11;
+		}`)
+	content := string(testFileContents["Test/Merging.elm"])
+	assert.Contains(t, content, "mergeLayered : Layered -> Layered -> Layered")
+	// Repeated messages on the wire are merged by the decoder too
+	assert.Contains(t, content, "xsub = mergeXSub m.xsub v")
+	tests := string(testFileContents["Test/MergingTests.elm"])
+	assert.Contains(t, tests, "mergeTestLayered : Test")
+	// Compared with the generated equality as records may hold bytes
+	assert.Contains(t, tests, "(Protobuf.ElmerTests.mergeTest Test.Merging.decodeLayered Test.Merging.encodeLayered Test.Merging.mergeLayered Test.Merging.equalLayered)")
+}
+
+func TestFieldMasks(t *testing.T) {
+	testElmFiles = map[string]string{"Test/MasksUsageTests.elm": `
+module Test.MasksUsageTests exposing (suite)
+
+import Expect
+import Test exposing (Test, describe, test)
+import Test.Masks as M
+
+
+suite : Test
+suite =
+    let
+        old =
+            M.emptyProfile
+
+        new =
+            { old | nick = "ed", address = { street = "high", number = 0 }, tags = [ "a" ] }
+    in
+    describe "field masks"
+        [ test "diff" <|
+            \_ -> M.diffProfile old new |> Expect.equal [ "nick", "address.street", "tags" ]
+        , test "patch" <|
+            \_ -> M.patchProfile [ "address.street" ] new old |> .address |> .street |> Expect.equal "high"
+        , test "patch whole message" <|
+            \_ -> M.patchProfile [ "address" ] new old |> .address |> Expect.equal new.address
+        , test "oneof" <|
+            \_ -> M.diffProfile old (M.setProfile_Email "x" old) |> Expect.equal [ "email" ]
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.masks;
+		message Address { string street = 1; int32 number = 2; }
+		message Profile {
+			string nick = 1;
+			Address address = 2;
+			repeated string tags = 3;
+			oneof contact { string email = 4; string phone = 5; }
+		}`)
+	content := string(testFileContents["Test/Masks.elm"])
+	assert.Contains(t, content, "diffProfile : Profile -> Profile -> List String")
+	assert.Contains(t, content, "patchProfile : List String -> Profile -> Profile -> Profile")
+	assert.Contains(t, content, `Protobuf.Elmer.diffNested "address" diffAddress a.address b.address`)
+	tests := string(testFileContents["Test/MasksTests.elm"])
+	assert.Contains(t, tests, "diffTestProfile : Test")
+}
+
+func TestTextFormat(t *testing.T) {
+	testCodecOptions.Text = true
+	testElmFiles = map[string]string{"Test/TextUsageTests.elm": `
+module Test.TextUsageTests exposing (suite)
+
+import Dict
+import Expect
+import Test exposing (Test, describe, test)
+import Test.Text as T
+import Time
+
+
+suite : Test
+suite =
+    let
+        fixture =
+            """
+            # A textproto fixture
+            title: "caf\\303\\251 \\"menu\\""
+            colour: RED
+            items { name: "tea" price: 2.5 }
+            items < name: 'cake' >
+            tags: ["a", "b"]
+            counts { key: "tea" value: 3 }
+            raw: "\\001\\x02"
+            flags { key: true value: GREEN }
+            at { seconds: 1 nanos: 500000000 }
+            wait { seconds: 90 nanos: 250000000 }
+            nothing {}
+            """
+    in
+    describe "text format"
+        [ test "parse" <|
+            \_ ->
+                T.fromTextMenu fixture
+                    |> Result.map (\m -> ( m.title, List.map .name m.items, Dict.toList m.counts ))
+                    |> Expect.equal (Ok ( "café \"menu\"", [ "tea", "cake" ], [ ( "tea", 3 ) ] ))
+        , test "round trip" <|
+            \_ ->
+                T.fromTextMenu fixture
+                    |> Result.andThen (\m -> T.fromTextMenu (T.toTextMenu m) |> Result.map (T.equalMenu m))
+                    |> Expect.equal (Ok True)
+        , test "print" <|
+            \_ ->
+                T.toTextMenu { emptyMenu | title = "x", special = Just (T.Menu_Soup "leek") }
+                    |> Expect.equal "title: \"x\"\nsoup: \"leek\""
+        , test "negative timestamp" <|
+            \_ ->
+                T.toTextMenu { emptyMenu | at = Time.millisToPosix -1500 }
+                    |> Expect.equal "at {\n  seconds: -2\n  nanos: 500000000\n}"
+        , test "negative timestamp round trip" <|
+            \_ ->
+                T.fromTextMenu (T.toTextMenu { emptyMenu | at = Time.millisToPosix -1500 })
+                    |> Result.map (.at >> Time.posixToMillis)
+                    |> Expect.equal (Ok -1500)
+        , test "unknown field" <|
+            \_ -> T.fromTextMenu "nope: 1" |> Expect.equal (Err "unknown field nope")
+        ]
+
+
+emptyMenu : T.Menu
+emptyMenu =
+    T.emptyMenu
+`}
+	defer func() {
+		testCodecOptions.Text = false
+		testElmFiles = nil
+	}()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.text;
+		import "google/protobuf/duration.proto";
+		import "google/protobuf/empty.proto";
+		import "google/protobuf/timestamp.proto";
+		enum Colour { COLOUR_UNSPECIFIED = 0; RED = 1; GREEN = 2; }
+		message Item { string name = 1; double price = 2; }
+		message Menu {
+			string title = 1;
+			Colour colour = 2;
+			repeated Item items = 3;
+			repeated string tags = 4;
+			map<string, int32> counts = 5;
+			bytes raw = 6;
+			map<bool, Colour> flags = 7;
+			oneof special { string soup = 8; Item dish = 9; }
+			google.protobuf.Timestamp at = 10;
+			google.protobuf.Duration wait = 11;
+			google.protobuf.Empty nothing = 12;
+		}`)
+	content := string(testFileContents["Test/Text.elm"])
+	assert.Contains(t, content, "toTextMenu : Menu -> String")
+	assert.Contains(t, content, "fromTextMenu : String -> Result String Menu")
+	assert.Contains(t, content, "textColour : Protobuf.Elmer.Text Colour")
+	assert.Contains(t, content, "Protobuf.Elmer.textDuration")
+	assert.Contains(t, content, "Protobuf.Elmer.textEmpty")
+}
@@ -0,0 +1,98 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestConformance(t *testing.T) {
+	testModule(t, `
+		syntax = "proto3";
+		package test.conformance;
+		import "google/protobuf/timestamp.proto";
+		enum Colour { RED = 0; GREEN = 1; }
+		message Leaf { string name = 1; }
+		message Scalars {
+			int32 a = 1;
+			string b = 2;
+			bytes c = 3;
+			Colour d = 4;
+			repeated float e = 5;
+			map<string, Leaf> f = 6;
+			google.protobuf.Timestamp g = 7;
+			optional bool h = 8;
+			oneof choice {
+				uint32 i = 9;
+				string j = 10;
+			}
+		}
+	`)
+	tests := string(testFileContents["Test/ConformanceConformanceTests.elm"])
+	assert.Contains(t, tests, `Protobuf.ElmerTests.conformance "test.conformance.Scalars #1"`)
+	assert.Contains(t, tests, `Protobuf.ElmerTests.conformance "test.conformance.Leaf #1" Test.Conformance.equalLeaf`)
+	assert.Contains(t, tests, `Protobuf.ElmerTests.conformanceJson "test.conformance.Leaf #1 JSON" Test.Conformance.equalLeaf Test.ConformanceJson.decodeLeaf Test.ConformanceJson.encodeLeaf`)
+	assert.Contains(t, tests, "import Test.ConformanceJson")
+	assert.NotContains(t, tests, "Skipped")
+}
+
+func TestConformanceUnsupported(t *testing.T) {
+	// Not valid Elm (recursive aliases) so the generators aren't run
+	plugin := testPlugin(t, `
+		syntax = "proto3";
+		package test.conformance;
+		import "google/protobuf/duration.proto";
+		message Big { int64 a = 1; }
+		message BigMap { map<string, Big> a = 1; }
+		message BigKey { map<int64, string> a = 1; }
+		message BigList { repeated Big a = 1; }
+		message Dur { google.protobuf.Duration a = 1; }
+		message Tree { Tree left = 1; }
+		message Forest { repeated Forest trees = 1; }
+		message Grove { map<string, Grove> groves = 1; }
+	`)
+	messages := plugin.Files[len(plugin.Files)-1].Desc.Messages()
+	unsupported := func(name string) string {
+		md := messages.ByName(protoreflect.Name(name))
+		return conformanceUnsupported(md, make(map[protoreflect.FullName]bool))
+	}
+	assert.Equal(t, "64-bit integers are unsupported", unsupported("Big"))
+	assert.Equal(t, "64-bit integers are unsupported", unsupported("BigMap"))
+	assert.Equal(t, "64-bit integers are unsupported", unsupported("BigKey"))
+	assert.Equal(t, "64-bit integers are unsupported", unsupported("BigList"))
+	assert.Equal(t, "well-known type google.protobuf.Duration is unsupported", unsupported("Dur"))
+	assert.Equal(t, "recursive messages can't be filled", unsupported("Tree"))
+	// Lists and maps may be empty
+	assert.Equal(t, "", unsupported("Forest"))
+	assert.Equal(t, "", unsupported("Grove"))
+}
+
+func TestConformanceElmString(t *testing.T) {
+	assert.Equal(t, `"plain"`, elmString("plain"))
+	assert.Equal(t, `"a \"quote\"\n\\"`, elmString("a \"quote\"\n\\"))
+}
+
+func TestConformanceJSON(t *testing.T) {
+	plugin := testPlugin(t, `
+		syntax = "proto3";
+		package test.conformance;
+		message Named { string first_name = 1; }
+	`)
+	pkgs := FilesToPackages(plugin.Files)
+	elm := NewModule("ConformanceTests", pkgs[len(pkgs)-1])
+	g := plugin.NewGeneratedFile("file", "")
+	assert.True(t, ConformanceOptions{Count: 1, Seed: 1, JSON: true}.Generate(elm, g))
+	assert.NoError(t, elm.Err())
+	content, err := g.Content()
+	assert.NoError(t, err)
+	// Compact protojson using JSON names
+	assert.Contains(t, string(content), `Protobuf.ElmerTests.conformanceJson "test.conformance.Named #1 JSON" Test.Conformance.equalNamed Test.ConformanceJson.decodeNamed Test.ConformanceJson.encodeNamed "{\"firstName\":\"bravo\"}" { firstName = "bravo" }`)
+}
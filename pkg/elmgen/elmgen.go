@@ -29,7 +29,7 @@ type (
 
 	// Top-level structures describing an Elm Module
 	Module struct {
-		importsSeen map[string]bool
+		importsSeen map[string]string // Module to its derived suffix (if any)
 
 		ProtoPackage string
 		Name, Path   string
@@ -39,16 +39,20 @@ type (
 		Oneofs   Oneofs
 		Records  Records
 		Services Services
+
+		Errors Errors // Unsupported schema found while building or generating. See Err
 	}
 
 	// Elm reference pointing an identifier e.g., a type or function in another module. Module is blank for local references.
 	ElmRef struct {
 		Module, ID string
 	}
-	// Speciality reference for a codegen type that has our derived functions. Refs are never nil. The fuzzer assumes a reference to another module with the "Tests" suffix (see `NewModule`)
+	// Speciality reference for a codegen type that has our derived functions. Refs are never nil. The fuzzer assumes a reference to another module with the "Tests" suffix and JSON codecs the "Json" suffix (see `NewModule`)
 	ElmType struct {
 		*ElmRef
 		Zero, Decoder, Encoder, Fuzzer *ElmRef
+		JSONDecoder, JSONEncoder       *ElmRef
+		Random, View, Form             *ElmRef
 	}
 
 	// Describes a set of comments from the Protobuf source
@@ -67,6 +71,7 @@ type (
 		Type     *ElmType
 		Variants []*Variant
 		Aliases  []*VariantAlias
+		Desc     protoreflect.EnumDescriptor
 		Comments *CommentSet
 	}
 	// Describes a Union tag
@@ -79,6 +84,7 @@ type (
 	// VariantAlias is a Variant with an alternative name and the same wire number. First Variant seen is the real one, subsequent are alternate names.
 	VariantAlias struct {
 		Alias    *ElmRef
+		Label    string
 		Variant  *Variant
 		Comments *CommentSet
 	}
@@ -105,6 +111,7 @@ type (
 	Record struct {
 		Type     *ElmType
 		Fields   []*Field
+		Desc     protoreflect.MessageDescriptor
 		Comments *CommentSet
 	}
 
@@ -133,11 +140,20 @@ type (
 		In, Out *ElmType
 
 		InStreaming, OutStreaming bool
+		InEmpty, OutEmpty         bool // google.protobuf.Empty is dropped from signatures
 
 		Service  protoreflect.FullName
 		Method   protoreflect.Name
+		HTTP     *HTTPRule   // Nil if there's no google.api.http option
+		Pages    *Pagination // Nil if the method isn't paginated
+		Desc     protoreflect.MethodDescriptor
 		Comments *CommentSet
 	}
+	// AIP-158 pagination. Requests have a page size and token, responses have the next page's token and a repeated field of results
+	Pagination struct {
+		PageSize, PageToken    protoreflect.FieldDescriptor
+		NextPageToken, Results protoreflect.FieldDescriptor
+	}
 )
 
 func (a Unions) Len() int           { return len(a) }
@@ -190,7 +206,7 @@ func FilesToPackages(files []*protogen.File) []*ProtoPackage {
 // Entry point for elmgen. Builds an Elm module from a given proto File. The module name may be suffixed to allow for different derivative use cases e.g., a codec with no suffix and the suffix "Twirp" for a client could live alongside each other.
 func NewModule(suffix string, input *ProtoPackage) *Module {
 	m := new(Module)
-	m.importsSeen = make(map[string]bool)
+	m.importsSeen = make(map[string]string)
 	// Paths
 	pkg := string(input.Name)
 	// Adding a prefix / suffix can prevent an empty can lead to X.elm and Tests.elm
@@ -20,6 +20,7 @@ import (
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/pluginpb"
 )
@@ -46,9 +47,12 @@ func testPlugin(t *testing.T, specs ...string) *protogen.Plugin {
 		err := os.WriteFile(fullProto, []byte(spec), 0644)
 		assert.NoError(t, err)
 	}
-	// Invoke protoc's parser
+	// Invoke protoc's parser. Includes vendored protos e.g., google/api
+	vendored, err := filepath.Abs("testdata/proto")
+	assert.NoError(t, err)
 	args := []string{
 		"--proto_path=" + tmpDir,
+		"--proto_path=" + vendored,
 		"--include_imports",
 		"--include_source_info",
 		"--descriptor_set_out=" + stdout}
@@ -56,7 +60,7 @@ func testPlugin(t *testing.T, specs ...string) *protogen.Plugin {
 	cmd := exec.Command("protoc", args...)
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
-	err := cmd.Run()
+	err = cmd.Run()
 	assert.NoError(t, err)
 	// Read contents of file stdout
 	descriptorBytes, err := os.ReadFile(stdout)
@@ -98,12 +102,23 @@ func runElmTest(projDir, globs string, fuzz int) error {
 	return cmd.Run()
 }
 
-var testFileContents map[string][]byte // For comment testing
+var testFileContents map[string][]byte                           // For comment testing
+var testPrevious *protoregistry.Files                            // Previous schema passed to the fuzzer
+var testElmFiles map[string]string                               // Extra Elm modules (by path) run alongside generated tests
+var testCodecOptions = CodecOptions{Strict: true, Setters: true} // Also run after the defaults. Text needs supported well-known types so is opt-in
 
 func testModule(t *testing.T, specs ...string) *Module {
 	plugin := testPlugin(t, specs...)
+	// Default codec options first (with its fuzz tests) then every generator with opt-in options
+	testGenerate(t, plugin, CodecOptions{})
+	return testGenerate(t, plugin, testCodecOptions)
+}
+
+// Generates Elm code for a plugin then runs its tests. Only the opt-in codec options run every generator and extra Elm modules
+func testGenerate(t *testing.T, plugin *protogen.Plugin, codecOpts CodecOptions) *Module {
 	testProjectDir := "./testdata/gen-elm"
 	testFileContents = make(map[string][]byte)
+	optIn := codecOpts != CodecOptions{}
 
 	// Remove old tests
 	err := os.RemoveAll(testProjectDir + "/src")
@@ -123,13 +138,42 @@ func testModule(t *testing.T, specs ...string) *Module {
 			// Generate file
 			genFile := plugin.NewGeneratedFile(elm.Path, "")
 			valid := gen(elm, genFile)
-			if suffix == "Twirp" {
-				if len(elm.Services) == 0 {
-					assert.False(t, valid)
-				} else {
-					assert.True(t, valid)
+			assert.NoError(t, elm.Err())
+			switch suffix {
+			case "Twirp", "TwirpMetadata", "TwirpEffects", "Streams":
+				// Only valid if there's a method to generate
+				var expValid bool
+				for _, s := range elm.Services {
+					for _, rpc := range s.Methods {
+						if rpc.IsStreaming() == (suffix == "Streams") {
+							expValid = true
+						}
+					}
+				}
+				assert.Equal(t, expValid, valid)
+				if !valid {
+					return
+				}
+			case "Rest":
+				// Only valid if there's an annotated method
+				var expValid bool
+				for _, s := range elm.Services {
+					for _, rpc := range s.Methods {
+						if !rpc.IsStreaming() && rpc.HTTP != nil {
+							expValid = true
+						}
+					}
+				}
+				assert.Equal(t, expValid, valid)
+				if !valid {
+					return
 				}
-			} else {
+			case "ConformanceTests":
+				// Not every package has a record protobuf-go can fill
+				if !valid {
+					return
+				}
+			default:
 				assert.True(t, valid)
 			}
 			// Always format (checks Elm syntax)
@@ -152,10 +196,31 @@ func testModule(t *testing.T, specs ...string) *Module {
 		}
 
 		// Run through all of our codegen
-		runGenerator("", GenerateCodec)
+		runGenerator("", codecOpts.Generate)
 		lastCodec = elm
-		runGenerator("Tests", GenerateFuzzTests)
+		runGenerator("Tests", FuzzOptions{Previous: testPrevious}.Generate)
+		if !optIn {
+			continue
+		}
+		runGenerator("Random", GenerateRandom)
+		runGenerator("View", GenerateView)
+		runGenerator("Explorer", GenerateExplorer)
 		runGenerator("Twirp", GenerateTwirp)
+		runGenerator("TwirpMetadata", TwirpOptions{Metadata: true}.Generate)
+		runGenerator("TwirpEffects", TwirpOptions{Metadata: true, Effects: true}.Generate)
+		runGenerator("Streams", GenerateStreams)
+		runGenerator("Json", GenerateJSON)
+		runGenerator("Rest", GenerateREST)
+		runGenerator("ConformanceTests", ConformanceOptions{Count: 3, Seed: 1, JSON: true}.Generate)
+	}
+	if optIn { // May use opt-in functions
+		for path, content := range testElmFiles {
+			fullFile := testProjectDir + "/src/" + path
+			err = os.MkdirAll(filepath.Dir(fullFile), 0755)
+			assert.NoError(t, err)
+			err = os.WriteFile(fullFile, []byte(content), 0644)
+			assert.NoError(t, err)
+		}
 	}
 	// Change pwd to tests
 	wd, err := os.Getwd()
@@ -184,7 +249,7 @@ func TestSpecialProto(t *testing.T) {
 
 func TestLocality(t *testing.T) {
 	m := new(Module)
-	m.importsSeen = make(map[string]bool)
+	m.importsSeen = make(map[string]string)
 	m.Name = "OurMod"
 	ref1 := m.newElmRef("NotOurs", "a")
 	ref2 := m.newElmRef("OurMod", "b")
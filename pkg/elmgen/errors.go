@@ -0,0 +1,109 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type (
+	// A part of the schema that can't be generated. Positioned by the descriptor's source location, if known
+	Error struct {
+		Desc    protoreflect.Descriptor
+		Message string
+	}
+
+	// Every error found in a run
+	Errors []*Error
+)
+
+// Formats as `file:line:column: ident: message`. Lines and columns are only known if the schema has source info
+func (e *Error) Error() string {
+	file := e.Desc.ParentFile()
+	loc := file.SourceLocations().ByDescriptor(e.Desc)
+	if loc.Path == nil { // No source info
+		return fmt.Sprintf("%s: %s: %s", file.Path(), e.Desc.FullName(), e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", file.Path(),
+		loc.StartLine+1, loc.StartColumn+1, e.Desc.FullName(), e.Message)
+}
+
+// One error per line, sorted by location
+func (errs Errors) Error() string {
+	sorted := append(Errors(nil), errs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Desc, sorted[j].Desc
+		if a.ParentFile().Path() != b.ParentFile().Path() {
+			return a.ParentFile().Path() < b.ParentFile().Path()
+		}
+		return a.ParentFile().SourceLocations().ByDescriptor(a).StartLine <
+			b.ParentFile().SourceLocations().ByDescriptor(b).StartLine
+	})
+	var lines []string
+	for _, e := range sorted {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Adds an error unless it's already been seen e.g., the same field failing its type and decoder
+func (errs Errors) add(e *Error) Errors {
+	for _, seen := range errs {
+		if seen.Desc.FullName() == e.Desc.FullName() && seen.Message == e.Message {
+			return errs
+		}
+	}
+	return append(errs, e)
+}
+
+// Adds errors from another run e.g., another generator on the same package. Duplicates are dropped
+func (errs Errors) Merge(other Errors) Errors {
+	for _, e := range other {
+		errs = errs.add(e)
+	}
+	return errs
+}
+
+// Records an error found while generating. Generated code is invalid and should be skipped
+func (m *Module) errorf(d protoreflect.Descriptor, formatter string, args ...interface{}) {
+	m.Errors = m.Errors.add(&Error{d, fmt.Sprintf(formatter, args...)})
+}
+
+// Returns errors found while building or generating the module. Nil if there aren't any
+func (m *Module) Err() error {
+	if len(m.Errors) == 0 {
+		return nil
+	}
+	return m.Errors
+}
+
+// Records a field kind we can't generate. Fields of map entries are reported on their map
+func (m *Module) unsupportedKind(fd protoreflect.FieldDescriptor) {
+	var d protoreflect.Descriptor = fd
+	if entry := fd.ContainingMessage(); entry != nil && entry.IsMapEntry() {
+		if parent, ok := entry.Parent().(protoreflect.MessageDescriptor); ok {
+			fields := parent.Fields()
+			for i := 0; i < fields.Len(); i++ {
+				if f := fields.Get(i); f.Message() != nil && f.Message().FullName() == entry.FullName() {
+					d = f
+				}
+			}
+		}
+	}
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+		protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+		m.errorf(d, "64-bit integers (%s) are unsupported by Elm", fd.Kind())
+	default:
+		m.errorf(d, "unsupported field type: %s", fd.Kind())
+	}
+}
@@ -0,0 +1,121 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplorer(t *testing.T) {
+	testElmFiles = map[string]string{"Test/ExplorerUsageTests.elm": `
+module Test.ExplorerUsageTests exposing (suite)
+
+import Expect
+import Test exposing (Test, describe, test)
+import Test.Admin as T
+import Test.AdminExplorer as E
+import Test.Html.Event as Event
+import Test.Html.Query as Query
+import Test.Html.Selector as Selector
+
+
+suite : Test
+suite =
+    let
+        shop =
+            T.emptyShop
+
+        tags =
+            T.emptyTags
+
+        model =
+            Tuple.first (E.init "/api")
+    in
+    describe "explorer"
+        [ test "edit a string" <|
+            \_ ->
+                Query.fromHtml (E.formShop shop)
+                    |> Query.findAll [ Selector.tag "input" ]
+                    |> Query.first
+                    |> Event.simulate (Event.input "corner")
+                    |> Event.expect { shop | name = "corner" }
+        , test "pick an enum" <|
+            \_ ->
+                Query.fromHtml (E.formColour T.ColourUnspecified)
+                    |> Query.find [ Selector.tag "select" ]
+                    |> Event.simulate (Event.input "1")
+                    |> Event.expect T.Red
+        , test "choose a oneof" <|
+            \_ ->
+                Query.fromHtml (E.formShop_Owner Nothing)
+                    |> Query.find [ Selector.tag "select" ]
+                    |> Event.simulate (Event.input "0")
+                    |> Event.expect (Just (T.Shop_Person ""))
+        , test "add to a list" <|
+            \_ ->
+                Query.fromHtml (E.formTags { tags | tags = [ "a" ] })
+                    |> Query.find [ Selector.tag "button", Selector.containing [ Selector.text "+" ] ]
+                    |> Event.simulate Event.click
+                    |> Event.expect { tags | tags = [ "a", "" ] }
+        , test "remove from a list" <|
+            \_ ->
+                Query.fromHtml (E.formTags { tags | tags = [ "a", "b" ] })
+                    |> Query.findAll [ Selector.tag "button", Selector.containing [ Selector.text "−" ] ]
+                    |> Query.first
+                    |> Event.simulate Event.click
+                    |> Event.expect { tags | tags = [ "b" ] }
+        , test "lists methods" <|
+            \_ ->
+                Query.fromHtml (E.view model)
+                    |> Query.has [ Selector.text "test.admin.Shops/Open", Selector.text "test.admin.Shops/Ping" ]
+        , test "pick a method" <|
+            \_ ->
+                E.update (E.Pick E.Shops_Ping) model
+                    |> Tuple.first
+                    |> .method
+                    |> Expect.equal E.Shops_Ping
+        , test "shows the response" <|
+            \_ ->
+                E.update (E.Got (Ok (E.ResponseShops_Open { tags = [ "tea" ] }))) model
+                    |> Tuple.first
+                    |> E.view
+                    |> Query.fromHtml
+                    |> Query.has [ Selector.text "\"tea\"" ]
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.admin;
+		import "google/protobuf/empty.proto";
+		enum Colour { COLOUR_UNSPECIFIED = 0; RED = 1; }
+		message Tags { repeated string tags = 1; }
+		message Shop {
+			string name = 1;
+			Colour colour = 2;
+			oneof owner { string person = 3; Tags labels = 4; }
+		}
+		service Shops {
+			rpc Open(Shop) returns (Tags);
+			rpc Ping(google.protobuf.Empty) returns (google.protobuf.Empty);
+			rpc Watch(Shop) returns (stream Tags);
+		}`)
+	content := string(testFileContents["Test/AdminExplorer.elm"])
+	assert.Contains(t, content, "formShop : Test.Admin.Shop -> Html Test.Admin.Shop")
+	assert.Contains(t, content, "formShop_Owner : Maybe Test.Admin.Shop_Owner -> Html (Maybe Test.Admin.Shop_Owner)")
+	assert.Contains(t, content, "formColour : Test.Admin.Colour -> Html Test.Admin.Colour")
+	assert.Contains(t, content, "Test.AdminTwirp.twirpShops_Open")
+	// Empty is dropped from requests and responses
+	assert.Contains(t, content, "Test.AdminTwirp.twirpShops_Ping (Result.map ResponseShops_Ping >> Got) model.api\n")
+	assert.Contains(t, content, "| ResponseShops_Ping ()")
+	assert.Contains(t, content, "main : Program () Model Msg")
+	assert.NotContains(t, content, "Shops_Watch")
+}
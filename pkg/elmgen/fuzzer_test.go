@@ -0,0 +1,113 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestFuzzPrevious(t *testing.T) {
+	testPrevious = testPreviousFiles(t, `
+		syntax = "proto3";
+		package test.evolution;
+		enum Colour { RED = 0; }
+		message Msg {
+			int32 a = 1;
+			string name = 2;
+			repeated string gone = 3;
+			optional bool opt = 4;
+			oneof choice { string s = 5; int32 i = 6; }
+			map<string, Sub> subs = 10;
+			Colour colour = 12;
+		}
+		message Sub { int32 z = 1; Sub old = 2; }
+	`)
+	defer func() { testPrevious = nil }()
+
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.evolution;
+		enum Colour { RED = 0; GREEN = 1; }
+		message Msg {
+			int32 a = 1;
+			string renamed = 2;
+			optional bool opt = 4;
+			oneof choice { string s = 5; int32 i = 6; Sub sub = 7; }
+			map<string, Sub> subs = 10;
+			int32 added = 11;
+			Colour colour = 12;
+		}
+		message Sub { int32 z = 1; }
+		message OnlyNew {}
+	`)
+	desc, err := testPrevious.FindDescriptorByName("test.evolution.Msg")
+	assert.NoError(t, err)
+	var labels []string
+	for _, f := range previousFields(elm, elm.Records[0].Fields, desc.(protoreflect.MessageDescriptor)) {
+		labels = append(labels, f.Label)
+	}
+	assert.Equal(t, []string{"a", "renamed", "opt", "choice", "subs", "colour"}, labels)
+
+	tests := string(testFileContents["Test/EvolutionTests.elm"])
+	assert.Contains(t, tests, "evolutionTestMsg : Test")
+	assert.Contains(t, tests, "evolutionTestXSub : Test")
+	assert.NotContains(t, tests, "evolutionTestOnlyNew")
+	// Fields only the previous version has are written as unknown fields
+	assert.Contains(t, tests, `( 3, PE.string "previous" )`)
+	assert.Contains(t, tests, "( 2, PE.message [ ( 1, PE.int32 1 ) ] )")
+	// Nested types take their previous shape
+	assert.Contains(t, tests, "evolutionEncodeXSub")
+	assert.Contains(t, tests, `subs = (Dict.map (\_ -> evolutionSharedXSub)) v.subs`)
+	assert.Contains(t, tests, "evolutionSharedColour : Test.Evolution.Colour -> Test.Evolution.Colour")
+}
+
+func TestFuzzPreviousBreaking(t *testing.T) {
+	previous := testPreviousFiles(t, `
+		syntax = "proto3";
+		package test.evolution;
+		message Msg {
+			int32 changed = 1;
+			bool opt = 2;
+			oneof a { string s = 3; }
+			oneof b { string x = 4; }
+		}
+	`)
+	plugin := testPlugin(t, `
+		syntax = "proto3";
+		package test.evolution;
+		message Msg {
+			float changed = 1;
+			optional bool opt = 2;
+			oneof a { string s = 3; string x = 4; }
+		}
+	`)
+	pkgs := FilesToPackages(plugin.Files)
+	elm := NewModule("Tests", pkgs[len(pkgs)-1])
+	g := plugin.NewGeneratedFile("file", "")
+	FuzzOptions{Previous: previous}.Generate(elm, g)
+	err := elm.Err()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "test.evolution.Msg.changed: field 1 changed type since the previous schema")
+	assert.Contains(t, err.Error(), "test.evolution.Msg.opt: field 2 changed presence since the previous schema")
+	assert.Contains(t, err.Error(), "test.evolution.Msg.x: field 4 moved oneof since the previous schema")
+}
+
+// Builds a previous schema for fuzz tests
+func testPreviousFiles(t *testing.T, spec string) *protoregistry.Files {
+	previous := testPlugin(t, spec)
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{
+		File: previous.Request.ProtoFile})
+	assert.NoError(t, err)
+	return files
+}
@@ -11,7 +11,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
 	"strings"
@@ -88,13 +87,13 @@ func (set *CommentSet) printDashDash(g *protogen.GeneratedFile) {
 	g.P(set.Leading)
 }
 
-// Prints the imports of a module. Since a `Module` holds references to tests via types, these should be skipped for non-test generators.
-func printImports(g *protogen.GeneratedFile, m *Module, skipTests bool) {
+// Prints the imports of a module. Since a `Module` holds references to derived modules (e.g., tests) via types, these are skipped unless their suffix is given.
+func printImports(g *protogen.GeneratedFile, m *Module, derived ...string) {
 	g.P("import Protobuf.Decode as PD")
 	g.P("import Protobuf.Encode as PE")
 	for _, i := range m.Imports {
-		// Skip tests? Since our Elm types always generate a reference to Tests, we need to be able to skip them
-		if skipTests && strings.HasSuffix(i, "Tests") {
+		// Skip derived? Since our Elm types always generate a reference to e.g., Tests, we need to be able to skip them
+		if suffix := m.importsSeen[i]; suffix != "" && !contains(derived, suffix) {
 			continue
 		}
 		switch i {
@@ -109,8 +108,29 @@ func printImports(g *protogen.GeneratedFile, m *Module, skipTests bool) {
 	}
 }
 
-// Generates Elm decoders and encoders (making a codec) to a file
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Options for generating codecs
+type CodecOptions struct {
+	Strict  bool // Also generate strict decoders that reject unknown fields and enum numbers
+	Setters bool // Also generate setters, update functions, lenses and prisms for records
+	Text    bool // Also generate text format (textproto) printers and parsers
+}
+
+// Generates a codec with default options
 func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
+	return CodecOptions{}.Generate(m, g)
+}
+
+// Generates Elm decoders and encoders (making a codec) to a file
+func (opts CodecOptions) Generate(m *Module, g *protogen.GeneratedFile) bool {
 	gFP := func(formatter string, args ...interface{}) {
 		g.P(fmt.Sprintf(formatter, args...))
 	}
@@ -131,7 +151,7 @@ func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
 		g.P("Records: (none)")
 	}
 	g.P("")
-	var docsValues, docsStr []string
+	var docsValues, docsStr, docsInt []string
 	if len(m.Unions) > 0 {
 		g.P("Unions:")
 		for _, u := range m.Unions {
@@ -140,13 +160,14 @@ func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
 			allTypes = append(allTypes, t)
 			docsValues = append(docsValues, "valuesOf"+t.ID)
 			docsStr = append(docsStr, "from"+t.ID, "to"+t.ID)
+			docsInt = append(docsInt, "fromInt"+t.ID, "toInt"+t.ID, "compare"+t.ID)
 		}
 	} else {
 		g.P("Unions: (none)")
 	}
 	g.P("")
 
-	g.P("Each type defined has a: decoder, encoder and an empty (zero value) function. In addition to this enums have valuesOf, to and from (string) functions. All functions take the form `decodeDerivedIdent` where `decode` is the purpose and `DerivedIdent` comes from the Protobuf ident.")
+	g.P("Each type defined has a: decoder, encoder and an empty (zero value) function. In addition to this enums have valuesOf, to and from (string), toInt and fromInt (wire number) and compare functions. All functions take the form `decodeDerivedIdent` where `decode` is the purpose and `DerivedIdent` comes from the Protobuf ident.")
 	g.P("")
 	g.P("Elm identifiers are derived directly from the Protobuf ID (a full ident). The package maps to a module and the rest of the ID is the type. Since Protobuf names are hierachical (separated by a dot `.`), each namespace is mapped to an underscore `_` in an Elm ID. A Protobuf namespaced ident (parts between a dot `.`) are then cased to follow Elm naming conventions and do not include any undescores `_`. For example the enum `my.pkg.MyMessage.URLOptions` maps to the Elm module `My.Pkg` with ID `MyMessage_UrlOptions`.")
 	g.P("")
@@ -168,14 +189,112 @@ func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
 		g.P("@docs ", strings.Join(docsValues, ", "))
 		g.P("# Enum and String converters")
 		g.P("@docs ", strings.Join(docsStr, ", "))
+		g.P("# Enum and wire number converters")
+		g.P("@docs ", strings.Join(docsInt, ", "))
+	}
+	var docsOneofs []string
+	for _, r := range m.Records {
+		for _, f := range r.Fields {
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				for _, v := range f.Oneof.Variants {
+					docsOneofs = append(docsOneofs, "is"+v.ID.ID, "get"+v.ID.ID, "set"+v.ID.ID)
+				}
+				docsOneofs = append(docsOneofs, "case"+f.Oneof.Type.ID)
+			}
+		}
+	}
+	if len(docsOneofs) > 0 {
+		g.P("# Oneof helpers")
+		g.P("@docs ", strings.Join(docsOneofs, ", "))
+	}
+	var docsMerge []string
+	for _, r := range m.Records {
+		docsMerge = append(docsMerge, "merge"+r.Type.ID)
+	}
+	if len(docsMerge) > 0 {
+		g.P("# Merging")
+		g.P("Protobuf's merge. The second record's fields win when set, lists are concatenated, maps are merged by key and messages recursively.")
+		g.P("@docs ", strings.Join(docsMerge, ", "))
+	}
+	var docsMasks []string
+	for _, r := range m.Records {
+		docsMasks = append(docsMasks, "diff"+r.Type.ID, "patch"+r.Type.ID)
+	}
+	if len(docsMasks) > 0 {
+		g.P("# Field masks")
+		g.P("Diffs list the `google.protobuf.FieldMask` paths that changed between two records. Patching copies those paths from a new record onto an old one.")
+		g.P("@docs ", strings.Join(docsMasks, ", "))
+	}
+	var docsEqual []string
+	for _, t := range allTypes {
+		docsEqual = append(docsEqual, "equal"+t.ID)
+	}
+	for _, o := range m.Oneofs {
+		if !o.IsSynthetic {
+			docsEqual = append(docsEqual, "equal"+o.Type.ID)
+		}
+	}
+	if len(docsEqual) > 0 {
+		g.P("# Equality")
+		g.P("Safe replacements for `==` which crashes on `Bytes`.")
+		g.P("@docs ", strings.Join(docsEqual, ", "))
 	}
 	g.P("# Decoders")
 	g.P("@docs ", strings.Join(docsDecs, ", "))
 	g.P("# Encoders")
 	g.P("@docs ", strings.Join(docsEncs, ", "))
+	if opts.Strict && len(m.Records) > 0 {
+		var docsStrict []string
+		for _, r := range m.Records {
+			docsStrict = append(docsStrict, "strict"+r.Type.ID, r.Type.Decoder.ID+"Strict")
+		}
+		g.P("# Strict decoders")
+		g.P("Reject payloads with unknown fields or enum numbers instead of skipping them.")
+		g.P("@docs ", strings.Join(docsStrict, ", "))
+	}
+	if opts.Setters && len(m.Records) > 0 {
+		var docsSetters, docsLenses []string
+		for _, r := range m.Records {
+			for _, f := range r.Fields {
+				suffix := r.Type.ID + setterSuffix(f)
+				docsSetters = append(docsSetters, "set"+suffix, "update"+suffix)
+				docsLenses = append(docsLenses, "lens"+suffix)
+			}
+		}
+		for _, o := range m.Oneofs {
+			if !o.IsSynthetic {
+				for _, v := range o.Variants {
+					docsLenses = append(docsLenses, "prism"+v.ID.ID)
+				}
+			}
+		}
+		g.P("# Setters")
+		g.P("@docs ", strings.Join(docsSetters, ", "))
+		g.P("# Lenses and prisms")
+		g.P("Compose with `Protobuf.Elmer.composeLens` and `Protobuf.Elmer.composePrism` to reach nested fields.")
+		g.P("@docs ", strings.Join(docsLenses, ", "))
+	}
+	if opts.Text && len(allTypes) > 0 {
+		var docsText []string
+		for _, t := range allTypes {
+			docsText = append(docsText, "text"+t.ID)
+		}
+		for _, r := range m.Records {
+			docsText = append(docsText, "toText"+r.Type.ID, "fromText"+r.Type.ID)
+		}
+		g.P("# Text format")
+		g.P("Prints and parses Protobuf's text format using the original field names e.g., for debugging or loading textproto fixtures.")
+		g.P("@docs ", strings.Join(docsText, ", "))
+	}
 	g.P("-}")
 	printDoNotEdit(g)
-	printImports(g, m, true)
+	printImports(g, m)
+	// Merging uses Protobuf.Elmer on every record
+	if len(m.Records) > 0 {
+		if !contains(m.Imports, importElmer) {
+			g.P("import ", importElmer)
+		}
+	}
 
 	// Unions
 	for _, u := range m.Unions {
@@ -286,83 +405,77 @@ func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
 			gFP(`    "%s" ->`, v.Label)
 			gFP(`      %s`, v.ID)
 		}
+		for _, a := range u.Aliases {
+			gFP(`    "%s" ->`, a.Label)
+			gFP(`      %s`, a.Variant.ID)
+		}
 		// No match? Use default
 		gFP("    _ ->")
 		gFP("      %s", u.Default().ID)
+
+		gFP("fromInt%s : %s -> Int", u.Type.ID, u.Type.ID)
+		gFP("fromInt%s u =", u.Type.ID)
+		gFP("  case u of")
+		for _, v := range u.Variants {
+			gFP(`    %s ->`, v.ID)
+			gFP(`      %d`, v.Number)
+		}
+
+		gFP("toInt%s : Int -> %s", u.Type.ID, u.Type.ID)
+		gFP("toInt%s n =", u.Type.ID)
+		gFP("  case n of")
+		for _, v := range u.Variants {
+			gFP(`    %d ->`, v.Number)
+			gFP(`      %s`, v.ID)
+		}
+		// Unrecognised? Use default, like decoding
+		gFP("    _ ->")
+		gFP("      %s", u.Default().ID)
+
+		// Orders by wire number
+		gFP("compare%s : %s -> %s -> Order", u.Type.ID, u.Type.ID, u.Type.ID)
+		gFP("compare%s a b =", u.Type.ID)
+		gFP("  compare (fromInt%s a) (fromInt%s b)", u.Type.ID, u.Type.ID)
 	}
 
-	// Record decoders
+	// Oneof helpers
 	for _, r := range m.Records {
-		gFP("%s : PD.Decoder %s", r.Type.Decoder, r.Type)
-		gFP("%s =", r.Type.Decoder)
-		// Build oneof decoders inline since they're unique to the message
-		// Ideally they'd be inline here (and in the decoder + fuzzer)
-		if oneofs := r.Oneofs(); len(oneofs) > 0 {
-			g.P("    let")
-			for _, f := range oneofs {
-				o := f.Oneof
-				gFP("        %s =", o.Type.Decoder)
-				g.P("            [")
-				for j, v := range o.Variants {
-					prefix := "            "
-					if j != 0 {
-						prefix += ","
-					}
-					wire := v.Field.Desc.Number()
-					decoder := fieldDecoder(m, v.Field.Desc)
-					if o.IsSynthetic { // Only field, skip map
-						gFP("%s( %d, %s )",
-							prefix, wire, decoder)
-					} else {
-						gFP("%s( %d, PD.map %s %s )",
-							prefix, wire, v.ID, decoder)
-					}
-				}
-				g.P("                ]")
+		for _, f := range r.Fields {
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				printOneofHelpers(g, m, r, f)
 			}
-			g.P("    in")
 		}
-		g.P("    PD.message ", r.Type.Zero)
-		g.P("        [")
-		for i, f := range r.Fields {
-			prefix := "            "
-			if i != 0 {
-				prefix += ","
-			}
-			getter := "(\\v m -> { m | %s = v })"
-			// Pick a FieldDecoder
-			if f.Oneof != nil {
-				gFP("%s PD.oneOf %s "+getter,
-					prefix, f.Oneof.Type.Decoder, f.Label)
-			} else {
-				wire := f.Desc.Number()
-				decoder := fieldDecoder(m, f.Desc)
-				if f.Desc.IsMap() {
-					key := f.Desc.MapKey()
-					val := f.Desc.MapValue()
-					gFP("%s PD.mapped %d ( %s , %s ) %s %s .%s "+getter,
-						prefix, wire,
-						fieldZero(m, key), fieldZero(m, val),
-						fieldDecoder(m, key), fieldDecoder(m, val),
-						f.Label, f.Label)
-				} else {
-					switch f.Desc.Cardinality() {
-					case protoreflect.Optional:
-						gFP("%s PD.optional %d %s "+getter,
-							prefix, wire, decoder, f.Label)
-
-					case protoreflect.Required:
-						gFP("%s PD.required %d %s "+getter,
-							prefix, wire, decoder, f.Label)
-
-					case protoreflect.Repeated:
-						gFP("%s PD.repeated %d %s .%s "+getter,
-							prefix, wire, decoder, f.Label, f.Label)
-					}
-				}
-			}
+	}
+
+	// Merging
+	for _, r := range m.Records {
+		printRecordMerge(g, m, r)
+	}
+
+	// Field masks
+	for _, r := range m.Records {
+		printRecordDiff(g, m, r)
+		printRecordPatch(g, m, r)
+	}
+
+	// Equality
+	for _, r := range m.Records {
+		printRecordEqual(g, m, r)
+	}
+	for _, u := range m.Unions {
+		gFP("equal%s : %s -> %s -> Bool", u.Type.ID, u.Type, u.Type)
+		gFP("equal%s =", u.Type.ID)
+		g.P("    (==)")
+	}
+	for _, o := range m.Oneofs {
+		if !o.IsSynthetic {
+			printOneofEqual(g, m, o)
 		}
-		g.P("        ]")
+	}
+
+	// Record decoders
+	for _, r := range m.Records {
+		printRecordDecoder(g, m, r.Type.Decoder.ID, r.Type, r.Fields)
 	}
 
 	// Union decoders
@@ -385,72 +498,7 @@ func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
 
 	// Record encoders
 	for _, r := range m.Records {
-		param := "v"
-		if len(r.Fields) == 0 {
-			param = "_"
-		}
-		gFP("%s : %s -> PE.Encoder", r.Type.Encoder, r.Type)
-		gFP("%s %s =", r.Type.Encoder, param)
-		oneofs := r.Oneofs()
-		if len(oneofs) > 0 {
-			g.P("    let")
-			for _, f := range oneofs {
-				o := f.Oneof
-				ws := "        "
-				gFP("%s%s o =", ws, o.Type.Encoder)
-				gFP("%s    case o of", ws)
-				ws += "        "
-				for _, v := range o.Variants {
-					f := v.Field
-					id := v.ID.String()
-					if o.IsSynthetic { // No sub-enum
-						id = ""
-					}
-					gFP("%sJust (%s data) ->", ws, id)
-					gFP("%s    [ ( %d, %s data ) ]",
-						ws, f.Desc.Number(), fieldEncoder(m, f.Desc))
-				}
-				// Nil isn't encoded on the wire
-				gFP("%sNothing ->", ws)
-				gFP("%s    []", ws)
-			}
-			g.P("    in")
-		}
-		g.P("    PE.message <|")
-		g.P("        [")
-		// Regular (non-oneof) fields
-		var written bool
-		for _, f := range r.Fields {
-			if f.Oneof != nil { // Skip
-				continue
-			}
-			prefix := "            "
-			if written { // Can't do i != 0 because of "continue"
-				prefix += ","
-			}
-			encoder := fieldEncoder(m, f.Desc)
-			// Special fields?
-			if f.Desc.IsMap() {
-				keyEnc := fieldEncoder(m, f.Desc.MapKey())
-				valEnc := fieldEncoder(m, f.Desc.MapValue())
-				gFP("%s ( %d, PE.dict %s %s v.%s )",
-					prefix, f.Desc.Number(), keyEnc, valEnc, f.Label)
-			} else if f.Desc.Cardinality() == protoreflect.Repeated {
-				gFP("%s ( %d, PE.list %s v.%s )",
-					prefix, f.Desc.Number(), encoder, f.Label)
-			} else {
-				gFP("%s ( %d, %s v.%s )",
-					prefix, f.Desc.Number(), encoder, f.Label)
-			}
-			written = true
-		}
-		g.P("        ]")
-		if len(oneofs) > 0 {
-			// Oneof field handling
-			for _, f := range oneofs {
-				gFP("        ++ %s v.%s", f.Oneof.Type.Encoder, f.Label)
-			}
-		}
+		printRecordEncoder(g, m, r.Type.Encoder.ID, r.Type, r.Fields, fieldEncoder)
 	}
 
 	// Union encoders
@@ -469,9 +517,788 @@ func GenerateCodec(m *Module, g *protogen.GeneratedFile) bool {
 		g.P("    PE.int32 conv")
 	}
 
+	if opts.Strict {
+		for _, r := range m.Records {
+			printStrictDecoder(g, m, r)
+		}
+	}
+	if opts.Text {
+		for _, u := range m.Unions {
+			gFP("text%s : %s.Text %s", u.Type.ID, importElmer, u.Type)
+			gFP("text%s =", u.Type.ID)
+			gFP("    %s.textEnum valuesOf%s from%s toInt%s", importElmer, u.Type.ID, u.Type.ID, u.Type.ID)
+		}
+		for _, r := range m.Records {
+			printTextCodec(g, m, r)
+		}
+	}
+	if opts.Setters {
+		for _, r := range m.Records {
+			printSetters(g, m, r)
+		}
+		for _, o := range m.Oneofs {
+			if !o.IsSynthetic {
+				printPrisms(g, m, o)
+			}
+		}
+	}
 	return true
 }
 
+// Setters are named after the record and field e.g., `setFooBar` for `Foo.bar`
+func setterSuffix(f *Field) string {
+	return strings.ToUpper(f.Label[:1]) + f.Label[1:]
+}
+
+// Prints a setter, update function and lens for each of a record's fields
+func printSetters(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	for _, f := range r.Fields {
+		suffix := r.Type.ID + setterSuffix(f)
+		typ := fieldType(m, f)
+		gFP("set%s : %s -> %s -> %s", suffix, typ, r.Type, r.Type)
+		gFP("set%s v m =", suffix)
+		gFP("    { m | %s = v }", f.Label)
+
+		gFP("update%s : (%s -> %s) -> %s -> %s", suffix, typ, typ, r.Type, r.Type)
+		gFP("update%s f m =", suffix)
+		gFP("    { m | %s = f m.%s }", f.Label, f.Label)
+
+		gFP("lens%s : %s.Lens %s %s", suffix, importElmer, r.Type, typ)
+		gFP("lens%s =", suffix)
+		gFP("    %s.Lens .%s set%s", importElmer, f.Label, suffix)
+	}
+}
+
+// Prints a prism for each of a oneof's variants. They focus on the record field i.e., `Maybe` the oneof
+func printPrisms(g *protogen.GeneratedFile, m *Module, o *Oneof) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	for _, v := range o.Variants {
+		id := "prism" + v.ID.ID
+		gFP("%s : %s.Prism (Maybe %s) %s", id, importElmer, o.Type, fieldType(m, v.Field))
+		gFP("%s =", id)
+		gFP("    %s.Prism", importElmer)
+		g.P("        (\\o ->")
+		g.P("            case o of")
+		gFP("                Just (%s v) ->", v.ID)
+		g.P("                    Just v")
+		g.P("                _ ->")
+		g.P("                    Nothing")
+		g.P("        )")
+		gFP("        (Just << %s)", v.ID)
+	}
+}
+
+// Prints a record's text format codec along with a printer and parser
+func printTextCodec(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	t := r.Type
+	gFP("text%s : %s.Text %s", t.ID, importElmer, t)
+	gFP("text%s =", t.ID)
+	gFP("    %s.textMessage %s", importElmer, t.Zero)
+	if len(r.Fields) == 0 {
+		g.P("        (\\_ -> [])")
+		gFP("        %s.textUnknown", importElmer)
+	} else {
+		// Printer
+		g.P("        (\\v ->")
+		g.P("            List.concat")
+		for i, f := range r.Fields {
+			prefix := "                ["
+			if i != 0 {
+				prefix = "                ,"
+			}
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				gFP("%s (case v.%s of", prefix, f.Label)
+				for _, v := range f.Oneof.Variants {
+					gFP("                    Just (%s x) ->", v.ID)
+					gFP("                        [ ( \"%s\", %s.print x ) ]", v.Field.Desc.Name(), fieldTextKind(m, v.Field.Desc))
+				}
+				g.P("                    Nothing ->")
+				g.P("                        [])")
+				continue
+			}
+			gFP("%s %s", prefix, fieldTextPrint(m, f))
+		}
+		g.P("                ]")
+		g.P("        )")
+
+		// Parser for each field
+		g.P("        (\\name ->")
+		g.P("            case name of")
+		for _, f := range r.Fields {
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				for _, v := range f.Oneof.Variants {
+					gFP("                \"%s\" ->", v.Field.Desc.Name())
+					gFP("                    %s.parseTextField %s (\\x m -> { m | %s = Just (%s x) })",
+						importElmer, fieldTextKind(m, v.Field.Desc), f.Label, v.ID)
+				}
+				continue
+			}
+			codec, set := fieldTextParse(m, f)
+			gFP("                \"%s\" ->", f.Desc.Name())
+			gFP("                    %s.parseTextField %s %s", importElmer, codec, set)
+		}
+		g.P("                _ ->")
+		gFP("                    %s.textUnknown name", importElmer)
+		g.P("        )")
+	}
+
+	gFP("toText%s : %s -> String", t.ID, t)
+	gFP("toText%s =", t.ID)
+	gFP("    %s.printText text%s", importElmer, t.ID)
+	gFP("fromText%s : String -> Result String %s", t.ID, t)
+	gFP("fromText%s =", t.ID)
+	gFP("    %s.parseText text%s", importElmer, t.ID)
+}
+
+// Prints a (non-oneof) field's text value(s) from record `v`
+func fieldTextPrint(m *Module, f *Field) string {
+	fd, name := f.Desc, f.Desc.Name()
+	switch {
+	case f.Oneof != nil: // Optional
+		return fmt.Sprintf("%s.textOptional \"%s\" %s v.%s", importElmer, name, fieldTextKind(m, fd), f.Label)
+
+	case fd.IsMap():
+		entries := "v." + f.Label
+		if toKey, _ := mapKeyConv(fd); toKey == "" {
+			entries = "(Dict.toList v." + f.Label + ")"
+		}
+		return fmt.Sprintf("%s.textRepeated \"%s\" %s %s", importElmer, name, fieldTextEntry(m, fd), entries)
+
+	case fd.IsList():
+		return fmt.Sprintf("%s.textRepeated \"%s\" %s v.%s", importElmer, name, fieldTextKind(m, fd), f.Label)
+	}
+	zero := fieldZero(m, fd)
+	if strings.HasPrefix(zero, "-") {
+		zero = "(" + zero + ")"
+	}
+	return fmt.Sprintf("%s.textSingular \"%s\" %s %s %s v.%s",
+		importElmer, name, fieldTextKind(m, fd), fieldEqualKind(m, fd), zero, f.Label)
+}
+
+// A (non-oneof) field's text codec and a function setting a parsed value on record `m`. Repeated values are appended
+func fieldTextParse(m *Module, f *Field) (codec, set string) {
+	fd, label := f.Desc, f.Label
+	switch {
+	case f.Oneof != nil: // Optional
+		return fieldTextKind(m, fd), fmt.Sprintf("(\\x m -> { m | %s = Just x })", label)
+
+	case fd.IsMap():
+		if toKey, fromKey := mapKeyConv(fd); toKey != "" { // Association list
+			return fieldTextEntry(m, fd), fmt.Sprintf(
+				"(\\( k, x ) m -> { m | %s = %s.dictToAssoc %s (Dict.insert (%s k) x (%s.assocToDict %s m.%s)) })",
+				label, importElmer, fromKey, toKey, importElmer, toKey, label)
+		}
+		return fieldTextEntry(m, fd), fmt.Sprintf("(\\( k, x ) m -> { m | %s = Dict.insert k x m.%s })", label, label)
+
+	case fd.IsList():
+		return fieldTextKind(m, fd), fmt.Sprintf("(\\x m -> { m | %s = m.%s ++ [ x ] })", label, label)
+	}
+	return fieldTextKind(m, fd), fmt.Sprintf("(\\x m -> { m | %s = x })", label)
+}
+
+// A map field's entry codec
+func fieldTextEntry(m *Module, fd protoreflect.FieldDescriptor) string {
+	key, val := fd.MapKey(), fd.MapValue()
+	valZero := fieldZero(m, val)
+	if strings.HasPrefix(valZero, "-") {
+		valZero = "(" + valZero + ")"
+	}
+	return fmt.Sprintf("(%s.textEntry %s %s %s %s)", importElmer,
+		fieldTextKind(m, key), fieldTextKind(m, val), fieldZero(m, key), valZero)
+}
+
+// Well-known types with a text codec in Protobuf.Elmer
+var textWellKnown = map[string]bool{
+	"BoolValue": true, "BytesValue": true, "DoubleValue": true,
+	"FloatValue": true, "Int32Value": true, "Int64Value": true,
+	"StringValue": true, "UInt32Value": true, "UInt64Value": true,
+	"Duration": true, "Empty": true, "Timestamp": true,
+}
+
+// Just the Kind's text codec. Does not take into account lists or maps
+func fieldTextKind(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return importElmer + ".textBool"
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Fixed32Kind:
+		return importElmer + ".textInt"
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return importElmer + ".textFloat"
+
+	case protoreflect.StringKind:
+		return importElmer + ".textString"
+
+	case protoreflect.BytesKind:
+		return importElmer + ".textBytes"
+
+	case protoreflect.EnumKind:
+		return m.fieldTextElmType(fd, fd.Enum().ParentFile(), fd.Enum())
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return m.fieldTextElmType(fd, fd.Message().ParentFile(), fd.Message())
+	}
+
+	m.unsupportedKind(fd)
+	return "Debug.todo \"unsupported\""
+}
+
+func (m *Module) fieldTextElmType(fd protoreflect.FieldDescriptor, p packager, d fullNamer) string {
+	if strings.HasPrefix(string(d.FullName()), "google.protobuf.") {
+		name := string(d.FullName().Name())
+		if !textWellKnown[name] {
+			m.errorf(fd, "well-known type %s has no text format", d.FullName())
+		}
+		return importElmer + ".text" + name
+	}
+	t := m.NewElmType(p, d)
+	return (&ElmRef{t.Module, "text" + t.ID}).String()
+}
+
+// Prints a record's strict description and a decoder checking payloads against it
+func printStrictDecoder(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	var fields []protoreflect.FieldDescriptor
+	for _, f := range r.Fields {
+		if f.Oneof != nil && !f.Oneof.IsSynthetic {
+			for _, v := range f.Oneof.Variants {
+				fields = append(fields, v.Field.Desc)
+			}
+		} else {
+			fields = append(fields, f.Desc)
+		}
+	}
+
+	id := "strict" + r.Type.ID
+	gFP("%s : %s.Strict", id, importElmer)
+	gFP("%s =", id)
+	gFP("    %s.strictMessage", importElmer)
+	for i, fd := range fields {
+		prefix := "        ["
+		if i != 0 {
+			prefix = "        ,"
+		}
+		gFP("%s ( %d, %s )", prefix, fd.Number(), fieldStrict(m, fd))
+	}
+	if len(fields) == 0 {
+		g.P("        [")
+	}
+	g.P("        ]")
+
+	gFP("%sStrict : PD.Decoder (Maybe %s)", r.Type.Decoder.ID, r.Type)
+	gFP("%sStrict =", r.Type.Decoder.ID)
+	gFP("    %s.decodeStrict %s %s", importElmer, id, r.Type.Decoder.ID)
+}
+
+// A field's strict description. Well-known types aren't checked
+func fieldStrict(m *Module, fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		return fmt.Sprintf("%s.strictNested (\\_ -> %s.strictMessage [ ( 1, %s ), ( 2, %s ) ])",
+			importElmer, importElmer, fieldStrict(m, fd.MapKey()), fieldStrict(m, fd.MapValue()))
+	}
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		var numbers []string
+		for i := 0; i < values.Len(); i++ {
+			numbers = append(numbers, fmt.Sprint(values.Get(i).Number()))
+		}
+		return importElmer + ".strictEnum [ " + strings.Join(numbers, ", ") + " ]"
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		if !strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+			t := m.NewElmType(md.ParentFile(), md)
+			ref := &ElmRef{t.Module, "strict" + t.ID}
+			return fmt.Sprintf("%s.strictNested (\\_ -> %s)", importElmer, ref)
+		}
+	}
+	return importElmer + ".strictScalar"
+}
+
+// Prints helpers for reading and writing a oneof's variants on its record without matching every case
+func printOneofHelpers(g *protogen.GeneratedFile, m *Module, r *Record, f *Field) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	o := f.Oneof
+	for _, v := range o.Variants {
+		id := v.ID.ID
+		gFP("is%s : %s -> Bool", id, r.Type)
+		gFP("is%s m =", id)
+		gFP("    case m.%s of", f.Label)
+		gFP("        Just (%s _) ->", v.ID)
+		g.P("            True")
+		g.P("        _ ->")
+		g.P("            False")
+
+		gFP("get%s : %s -> Maybe %s", id, r.Type, fieldType(m, v.Field))
+		gFP("get%s m =", id)
+		gFP("    case m.%s of", f.Label)
+		gFP("        Just (%s v) ->", v.ID)
+		g.P("            Just v")
+		g.P("        _ ->")
+		g.P("            Nothing")
+
+		gFP("set%s : %s -> %s -> %s", id, fieldType(m, v.Field), r.Type, r.Type)
+		gFP("set%s v m =", id)
+		gFP("    { m | %s = Just (%s v) }", f.Label, v.ID)
+	}
+
+	// Names the variant set e.g., for analytics
+	gFP("case%s : %s -> Maybe String", o.Type.ID, r.Type)
+	gFP("case%s m =", o.Type.ID)
+	gFP("    case m.%s of", f.Label)
+	for _, v := range o.Variants {
+		gFP("        Just (%s _) ->", v.ID)
+		gFP("            Just \"%s\"", v.Field.Desc.Name())
+	}
+	g.P("        Nothing ->")
+	g.P("            Nothing")
+}
+
+// Prints a record's merge function. Mirrors what decoding two concatenated encodings does
+func printRecordMerge(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	id := "merge" + r.Type.ID
+	gFP("%s : %s -> %s -> %s", id, r.Type, r.Type, r.Type)
+	if len(r.Fields) == 0 {
+		gFP("%s a _ =", id)
+		g.P("    a")
+		return
+	}
+	gFP("%s a b =", id)
+	g.P("    { a")
+	for i, f := range r.Fields {
+		prefix := "        |"
+		if i != 0 {
+			prefix = "        ,"
+		}
+		gFP("%s %s = %s a.%s b.%s", prefix, f.Label, fieldMerge(m, f), f.Label, f.Label)
+	}
+	g.P("    }")
+}
+
+// A field's merge function taking the old then new value
+func fieldMerge(m *Module, f *Field) string {
+	fd := f.Desc
+	switch {
+	case f.Oneof != nil: // Replaced, including optional fields
+		return importElmer + ".mergeMaybe"
+
+	case fd.IsMap():
+		if toKey, fromKey := mapKeyConv(fd); toKey != "" { // Association list
+			return "(" + importElmer + ".mergeAssoc " + toKey + " " + fromKey + ")"
+		}
+		return importElmer + ".mergeDict"
+
+	case fd.IsList():
+		return "(++)"
+	}
+	return fieldMergeKind(m, fd)
+}
+
+// Merges a singular value. Messages are merged recursively, everything else is overwritten when set
+func fieldMergeKind(m *Module, fd protoreflect.FieldDescriptor) string {
+	if md := fd.Message(); md != nil && !strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+		t := m.NewElmType(md.ParentFile(), md)
+		return (&ElmRef{t.Module, "merge" + t.ID}).String()
+	}
+	zero := fieldZero(m, fd)
+	if strings.HasPrefix(zero, "-") {
+		zero = "(" + zero + ")"
+	}
+	return fmt.Sprintf("(%s.mergeSingular %s %s)", importElmer, fieldEqualKind(m, fd), zero)
+}
+
+// Prints a record's diff function. Returns FieldMask paths, recursing into singular messages
+func printRecordDiff(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	id := "diff" + r.Type.ID
+	gFP("%s : %s -> %s -> List String", id, r.Type, r.Type)
+	if len(r.Fields) == 0 {
+		gFP("%s _ _ =", id)
+		g.P("    []")
+		return
+	}
+	gFP("%s a b =", id)
+	g.P("    List.concat")
+	for i, f := range r.Fields {
+		prefix := "        ["
+		if i != 0 {
+			prefix = "        ,"
+		}
+		var diff string
+		if f.Oneof != nil && !f.Oneof.IsSynthetic { // Whole value, named by its variants
+			caseID := "case" + f.Oneof.Type.ID
+			diff = fmt.Sprintf("%s.diffOneof (%s.equalMaybe equal%s a.%s b.%s) (%s a) (%s b)",
+				importElmer, importElmer, f.Oneof.Type.ID, f.Label, f.Label, caseID, caseID)
+		} else if f.Oneof != nil { // Optional
+			diff = fmt.Sprintf("%s.diffField \"%s\" (%s.equalMaybe %s) a.%s b.%s",
+				importElmer, f.Desc.Name(), importElmer, fieldEqual(m, f.Desc), f.Label, f.Label)
+		} else if ref := fieldMaskNested(m, f.Desc, "diff"); ref != nil {
+			diff = fmt.Sprintf("%s.diffNested \"%s\" %s a.%s b.%s",
+				importElmer, f.Desc.Name(), ref, f.Label, f.Label)
+		} else {
+			diff = fmt.Sprintf("%s.diffField \"%s\" %s a.%s b.%s",
+				importElmer, f.Desc.Name(), fieldEqual(m, f.Desc), f.Label, f.Label)
+		}
+		gFP("%s %s", prefix, diff)
+	}
+	g.P("        ]")
+}
+
+// Prints a record's patch function. Copies the masked paths from the new record onto the old
+func printRecordPatch(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	id := "patch" + r.Type.ID
+	gFP("%s : List String -> %s -> %s -> %s", id, r.Type, r.Type, r.Type)
+	if len(r.Fields) == 0 {
+		gFP("%s _ _ old =", id)
+		g.P("    old")
+		return
+	}
+	gFP("%s paths new old =", id)
+	g.P("    { old")
+	for i, f := range r.Fields {
+		prefix := "        |"
+		if i != 0 {
+			prefix = "        ,"
+		}
+		var patch string
+		if f.Oneof != nil && !f.Oneof.IsSynthetic {
+			var names []string
+			for _, v := range f.Oneof.Variants {
+				names = append(names, fmt.Sprintf("\"%s\"", v.Field.Desc.Name()))
+			}
+			patch = fmt.Sprintf("%s.patchOneof [ %s ] paths", importElmer, strings.Join(names, ", "))
+		} else if ref := fieldMaskNested(m, f.Desc, "patch"); ref != nil && f.Oneof == nil {
+			patch = fmt.Sprintf("%s.patchNested \"%s\" %s paths", importElmer, f.Desc.Name(), ref)
+		} else {
+			patch = fmt.Sprintf("%s.patchField \"%s\" paths", importElmer, f.Desc.Name())
+		}
+		gFP("%s %s = %s new.%s old.%s", prefix, f.Label, patch, f.Label, f.Label)
+	}
+	g.P("    }")
+}
+
+// Singular, user-defined messages are recursed into by field masks. Returns nil for everything else
+func fieldMaskNested(m *Module, fd protoreflect.FieldDescriptor, prefix string) *ElmRef {
+	md := fd.Message()
+	if md == nil || fd.IsList() || fd.IsMap() || strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+		return nil
+	}
+	t := m.NewElmType(md.ParentFile(), md)
+	return &ElmRef{t.Module, prefix + t.ID}
+}
+
+// Prints a record's equality function. Fields are compared in order
+func printRecordEqual(g *protogen.GeneratedFile, m *Module, r *Record) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	id := "equal" + r.Type.ID
+	gFP("%s : %s -> %s -> Bool", id, r.Type, r.Type)
+	if len(r.Fields) == 0 {
+		gFP("%s _ _ =", id)
+		g.P("    True")
+		return
+	}
+	gFP("%s a b =", id)
+	for i, f := range r.Fields {
+		prefix := "    "
+		if i != 0 {
+			prefix = "        && "
+		}
+		var eq string
+		if f.Oneof == nil {
+			eq = fieldEqual(m, f.Desc)
+		} else if f.Oneof.IsSynthetic {
+			eq = "(" + importElmer + ".equalMaybe " + fieldEqual(m, f.Desc) + ")"
+		} else {
+			eq = "(" + importElmer + ".equalMaybe equal" + f.Oneof.Type.ID + ")"
+		}
+		gFP("%s%s a.%s b.%s", prefix, eq, f.Label, f.Label)
+	}
+}
+
+// Prints a oneof's equality function. Different variants are never equal
+func printOneofEqual(g *protogen.GeneratedFile, m *Module, o *Oneof) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	id := "equal" + o.Type.ID
+	gFP("%s : %s -> %s -> Bool", id, o.Type, o.Type)
+	gFP("%s a b =", id)
+	g.P("    case ( a, b ) of")
+	for _, v := range o.Variants {
+		gFP("        ( %s x, %s y ) ->", v.ID, v.ID)
+		gFP("            %s x y", fieldEqual(m, v.Field.Desc))
+	}
+	// Avoid a redundant pattern
+	if len(o.Variants) > 1 {
+		g.P("        _ ->")
+		g.P("            False")
+	}
+}
+
+// A field's equality function including lists and maps
+func fieldEqual(m *Module, fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		val := fieldEqual(m, fd.MapValue())
+		if toKey, _ := mapKeyConv(fd); toKey != "" { // Association list
+			return "(" + importElmer + ".equalAssoc " + val + ")"
+		}
+		return "(" + importElmer + ".equalDict " + val + ")"
+	} else if fd.IsList() {
+		return "(" + importElmer + ".equalList " + fieldEqualKind(m, fd) + ")"
+	}
+	return fieldEqualKind(m, fd)
+}
+
+func fieldEqualKind(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BytesKind:
+		return importElmer + ".equalBytes"
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		if !strings.HasPrefix(string(ed.FullName()), "google.protobuf.") {
+			t := m.NewElmType(ed.ParentFile(), ed)
+			return (&ElmRef{t.Module, "equal" + t.ID}).String()
+		}
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		t := m.NewElmType(md.ParentFile(), md)
+		if !strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+			return (&ElmRef{t.Module, "equal" + t.ID}).String()
+		} else if messageHasBytes(md, make(map[protoreflect.FullName]bool)) {
+			// Well-known types have no equality functions, compare their encoding instead
+			return "(" + importElmer + ".equalEncoded " + t.Encoder.String() + ")"
+		}
+	}
+	return "(==)"
+}
+
+// Returns true if a message holds bytes, directly or via other messages
+func messageHasBytes(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) bool {
+	if seen[md.FullName()] {
+		return false
+	}
+	seen[md.FullName()] = true
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		if fd.Kind() == protoreflect.BytesKind ||
+			(fd.Message() != nil && messageHasBytes(fd.Message(), seen)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prints a record's decoder named id. Only the given fields are decoded, others are left as zero values
+func printRecordDecoder(g *protogen.GeneratedFile, m *Module, id string, t *ElmType, fields []*Field) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	gFP("%s : PD.Decoder %s", id, t)
+	gFP("%s =", id)
+	// Build oneof decoders inline since they're unique to the message
+	// Ideally they'd be inline here (and in the decoder + fuzzer)
+	if oneofs := oneofsOf(fields); len(oneofs) > 0 {
+		g.P("    let")
+		for _, f := range oneofs {
+			o := f.Oneof
+			gFP("        %s =", o.Type.Decoder.ID)
+			g.P("            [")
+			for j, v := range o.Variants {
+				prefix := "            "
+				if j != 0 {
+					prefix += ","
+				}
+				wire := v.Field.Desc.Number()
+				decoder := fieldDecoder(m, v.Field.Desc)
+				if o.IsSynthetic { // Only field, skip map
+					gFP("%s( %d, %s )",
+						prefix, wire, decoder)
+				} else {
+					gFP("%s( %d, PD.map %s %s )",
+						prefix, wire, v.ID, decoder)
+				}
+			}
+			g.P("                ]")
+		}
+		g.P("    in")
+	}
+	g.P("    PD.message ", t.Zero)
+	g.P("        [")
+	for i, f := range fields {
+		prefix := "            "
+		if i != 0 {
+			prefix += ","
+		}
+		getter := "(\\v m -> { m | %s = v })"
+		// Pick a FieldDecoder
+		if f.Oneof != nil {
+			gFP("%s PD.oneOf %s "+getter,
+				prefix, f.Oneof.Type.Decoder.ID, f.Label)
+		} else {
+			wire := f.Desc.Number()
+			decoder := fieldDecoder(m, f.Desc)
+			if f.Desc.IsMap() {
+				key := f.Desc.MapKey()
+				val := f.Desc.MapValue()
+				if toKey, fromKey := mapKeyConv(f.Desc); toKey != "" {
+					// Association list: decode via a Dict for last-wins
+					gFP("%s PD.mapped %d ( %s %s , %s ) (PD.map %s %s) %s (.%s >> %s.assocToDict %s) (\\v m -> { m | %s = %s.dictToAssoc %s v })",
+						prefix, wire,
+						toKey, fieldZero(m, key), fieldZero(m, val),
+						toKey, fieldDecoder(m, key), fieldDecoder(m, val),
+						f.Label, importElmer, toKey,
+						f.Label, importElmer, fromKey)
+				} else {
+					gFP("%s PD.mapped %d ( %s , %s ) %s %s .%s "+getter,
+						prefix, wire,
+						fieldZero(m, key), fieldZero(m, val),
+						fieldDecoder(m, key), fieldDecoder(m, val),
+						f.Label, f.Label)
+				}
+			} else {
+				setter := fmt.Sprintf(getter, f.Label)
+				if f.Desc.Message() != nil { // Messages found more than once are merged
+					setter = fmt.Sprintf("(\\v m -> { m | %s = %s m.%s v })",
+						f.Label, fieldMergeKind(m, f.Desc), f.Label)
+				}
+				switch f.Desc.Cardinality() {
+				case protoreflect.Optional:
+					gFP("%s PD.optional %d %s %s",
+						prefix, wire, decoder, setter)
+
+				case protoreflect.Required:
+					gFP("%s PD.required %d %s %s",
+						prefix, wire, decoder, setter)
+
+				case protoreflect.Repeated:
+					gFP("%s PD.repeated %d %s .%s "+getter,
+						prefix, wire, decoder, f.Label, f.Label)
+				}
+			}
+		}
+	}
+	g.P("        ]")
+}
+
+// Prints a record's encoder named id. Only the given fields are encoded, each kind with encoder. Extra fields are Elm `( number, encoder )` tuples written after them
+func printRecordEncoder(g *protogen.GeneratedFile, m *Module, id string, t *ElmType, fields []*Field,
+	encoder func(*Module, protoreflect.FieldDescriptor) string, extra ...string) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	param := "v"
+	if len(fields) == 0 {
+		param = "_"
+	}
+	gFP("%s : %s -> PE.Encoder", id, t)
+	gFP("%s %s =", id, param)
+	oneofs := oneofsOf(fields)
+	if len(oneofs) > 0 {
+		g.P("    let")
+		for _, f := range oneofs {
+			o := f.Oneof
+			ws := "        "
+			gFP("%s%s o =", ws, o.Type.Encoder.ID)
+			gFP("%s    case o of", ws)
+			ws += "        "
+			for _, v := range o.Variants {
+				f := v.Field
+				variant := v.ID.String()
+				if o.IsSynthetic { // No sub-enum
+					variant = ""
+				}
+				gFP("%sJust (%s data) ->", ws, variant)
+				gFP("%s    [ ( %d, %s data ) ]",
+					ws, f.Desc.Number(), encoder(m, f.Desc))
+			}
+			// Nil isn't encoded on the wire
+			gFP("%sNothing ->", ws)
+			gFP("%s    []", ws)
+		}
+		g.P("    in")
+	}
+	g.P("    PE.message <|")
+	g.P("        [")
+	// Regular (non-oneof) fields
+	var written bool
+	for _, f := range fields {
+		if f.Oneof != nil { // Skip
+			continue
+		}
+		prefix := "            "
+		if written { // Can't do i != 0 because of "continue"
+			prefix += ","
+		}
+		enc := encoder(m, f.Desc)
+		// Special fields?
+		if f.Desc.IsMap() {
+			keyEnc := encoder(m, f.Desc.MapKey())
+			valEnc := encoder(m, f.Desc.MapValue())
+			if toKey, fromKey := mapKeyConv(f.Desc); toKey != "" {
+				gFP("%s ( %d, PE.dict (%s >> %s) %s (%s.assocToDict %s v.%s) )",
+					prefix, f.Desc.Number(), fromKey, keyEnc, valEnc,
+					importElmer, toKey, f.Label)
+			} else {
+				gFP("%s ( %d, PE.dict %s %s v.%s )",
+					prefix, f.Desc.Number(), keyEnc, valEnc, f.Label)
+			}
+		} else if f.Desc.Cardinality() == protoreflect.Repeated {
+			gFP("%s ( %d, PE.list %s v.%s )",
+				prefix, f.Desc.Number(), enc, f.Label)
+		} else {
+			gFP("%s ( %d, %s v.%s )",
+				prefix, f.Desc.Number(), enc, f.Label)
+		}
+		written = true
+	}
+	for _, e := range extra {
+		prefix := "            "
+		if written {
+			prefix += ","
+		}
+		gFP("%s %s", prefix, e)
+		written = true
+	}
+	g.P("        ]")
+	if len(oneofs) > 0 {
+		// Oneof field handling
+		for _, f := range oneofs {
+			gFP("        ++ %s v.%s", f.Oneof.Type.Encoder.ID, f.Label)
+		}
+	}
+}
+
 func fieldType(m *Module, f *Field) string {
 	if f.Oneof != nil {
 		var inner string
@@ -489,6 +1316,9 @@ func fieldTypeDesc(m *Module, fd protoreflect.FieldDescriptor) string {
 	if fd.IsMap() {
 		key := fieldTypeKind(m, fd.MapKey())
 		val := fieldTypeDesc(m, fd.MapValue())
+		if toKey, _ := mapKeyConv(fd); toKey != "" {
+			return "(List ( " + key + ", " + val + " ))"
+		}
 		return "(Dict " + key + " " + val + ")"
 	} else if fd.IsList() {
 		val := fieldTypeKind(m, fd)
@@ -497,6 +1327,14 @@ func fieldTypeDesc(m *Module, fd protoreflect.FieldDescriptor) string {
 	return fieldTypeKind(m, fd)
 }
 
+// Elm's Dict needs comparable keys. Maps with other keys (bools) are association lists sorted by key, converted via a Dict with comparable keys. Returns the Elm functions converting to and from that key or empty strings if the key is comparable
+func mapKeyConv(fd protoreflect.FieldDescriptor) (toKey, fromKey string) {
+	if fd.MapKey().Kind() == protoreflect.BoolKind {
+		return importElmer + ".boolToKey", importElmer + ".keyToBool"
+	}
+	return "", ""
+}
+
 func fieldTypeKind(m *Module, fd protoreflect.FieldDescriptor) string {
 	switch fd.Kind() {
 	case protoreflect.BoolKind:
@@ -528,12 +1366,15 @@ func fieldTypeKind(m *Module, fd protoreflect.FieldDescriptor) string {
 		return m.NewElmType(md.ParentFile(), md).String()
 	}
 
-	log.Panicf("fieldType: unknown protoreflect.Kind: %s", fd.Kind())
-	return ""
+	m.unsupportedKind(fd)
+	return "Never"
 }
 
 func fieldZero(m *Module, fd protoreflect.FieldDescriptor) string {
 	if fd.IsMap() { // Dict
+		if toKey, _ := mapKeyConv(fd); toKey != "" { // Association list
+			return "[]"
+		}
 		return "Dict.empty"
 	} else if fd.IsList() { // List
 		return "[]"
@@ -571,8 +1412,8 @@ func fieldZero(m *Module, fd protoreflect.FieldDescriptor) string {
 		return m.NewElmType(md.ParentFile(), md).Zero.String()
 	}
 
-	log.Panicf("fieldZero: unknown protoreflect.Kind: %s", fd.Kind())
-	return ""
+	m.unsupportedKind(fd)
+	return "Debug.todo \"unsupported\""
 }
 
 func fieldDecoder(m *Module, fd protoreflect.FieldDescriptor) string {
@@ -623,8 +1464,8 @@ func fieldCodecKind(m *Module, lib string, fd protoreflect.FieldDescriptor) stri
 		return m.fieldCodecElmType(lib, md.ParentFile(), md)
 	}
 
-	log.Panicf("fieldCodec: unknown protoreflect.Kind: %s", fd.Kind())
-	return ""
+	m.unsupportedKind(fd)
+	return lib + "unsupported"
 }
 
 func (m *Module) fieldCodecElmType(lib string, p packager, d fullNamer) string {
@@ -0,0 +1,380 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/protorand"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Options for generating conformance tests
+type ConformanceOptions struct {
+	Count int   // Messages per record
+	Seed  int64 // Seeds the random messages. Keeps tests stable between runs
+	JSON  bool  // Also checks the JSON codecs (from protoc-gen-elmer-rest) against protojson
+}
+
+// Generates conformance tests with default options
+func GenerateConformance(m *Module, g *protogen.GeneratedFile) bool {
+	return ConformanceOptions{Count: 3, Seed: 1}.Generate(m, g)
+}
+
+// Generates tests checking our codecs against protobuf-go. Random messages are encoded by protobuf-go then decoded in Elm and compared against an equivalent Elm literal. The literal is then encoded and must match protobuf-go's bytes. With JSON, protobuf-go's JSON must also decode to the literal and the literal must survive our JSON codec.
+func (opts ConformanceOptions) Generate(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	r := rand.New(rand.NewSource(opts.Seed))
+	fill := protorand.Options{MaxDepth: 8, MaxLen: 2, NonZero: true}
+	lit := &conformanceLiterals{m, make(map[string]bool)}
+
+	var skipped, tests []string
+	for _, rec := range m.Records {
+		md := rec.Desc
+		if reason := conformanceUnsupported(md, make(map[protoreflect.FullName]bool)); reason != "" {
+			skipped = append(skipped, fmt.Sprintf("-- Skipped %s: %s", md.FullName(), reason))
+			continue
+		}
+		for i := 1; i <= opts.Count; i++ {
+			msg := dynamicpb.NewMessage(md)
+			protorand.Fill(r, msg, fill)
+			wire, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+			if err != nil {
+				m.errorf(md, "conformance: %s", err)
+				break
+			}
+			equal := &ElmRef{rec.Type.Module, "equal" + rec.Type.ID}
+			literal := lit.message(msg)
+			tests = append(tests, fmt.Sprintf(`%s.conformance "%s #%d" %s %s %s "%s" %s`,
+				importElmerTests, md.FullName(), i, equal, rec.Type.Decoder, rec.Type.Encoder,
+				base64.StdEncoding.EncodeToString(wire), literal))
+			if !opts.JSON {
+				continue
+			}
+			// Output isn't stable (spaces are randomised) so compact it
+			var compact bytes.Buffer
+			raw, err := protojson.Marshal(msg)
+			if err == nil {
+				err = json.Compact(&compact, raw)
+			}
+			if err != nil {
+				m.errorf(md, "conformance: %s", err)
+				break
+			}
+			tests = append(tests, fmt.Sprintf(`%s.conformanceJson "%s #%d JSON" %s %s %s %s %s`,
+				importElmerTests, md.FullName(), i, equal, rec.Type.JSONDecoder, rec.Type.JSONEncoder,
+				elmString(compact.String()), literal))
+		}
+	}
+	if len(tests) == 0 {
+		return false
+	}
+
+	gFP("module %s exposing (..)", m.Name)
+	gFP("{-| Protobuf library for testing structures found in package `" + m.ProtoPackage + "` against protobuf-go. This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	g.P("import Test exposing (Test, describe)")
+	gFP("import %s", importElmerTests)
+	if opts.JSON {
+		printImports(g, m, "Json")
+	} else {
+		printImports(g, m)
+	}
+	// Literals may reference modules the codec doesn't
+	var extra []string
+	for mod := range lit.imports {
+		if !contains(m.Imports, mod) {
+			extra = append(extra, mod)
+		}
+	}
+	sort.Strings(extra)
+	for _, mod := range extra {
+		gFP("import %s", mod)
+	}
+
+	for _, s := range skipped {
+		g.P(s)
+	}
+	g.P("suite : Test")
+	g.P("suite =")
+	gFP(`    describe "Conformance with protobuf-go"`)
+	for i, t := range tests {
+		prefix := ","
+		if i == 0 {
+			prefix = "["
+		}
+		gFP("        %s %s", prefix, t)
+	}
+	g.P("        ]")
+	return true
+}
+
+// Well-known types that map to Elm values we can write literals for
+var conformanceWellKnown = map[protoreflect.FullName]bool{
+	"google.protobuf.Timestamp":   true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.BytesValue":  true,
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.UInt32Value": true,
+}
+
+// Returns why a message can't be tested for conformance. Empty if it can
+func conformanceUnsupported(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) string {
+	if seen[md.FullName()] {
+		return "recursive messages can't be filled"
+	}
+	seen[md.FullName()] = true
+	defer delete(seen, md.FullName())
+	if md.Syntax() != protoreflect.Proto3 {
+		return "only proto3 is supported"
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		// Lists and maps may be empty so recursion is fine
+		many := fd.IsList() || fd.IsMap()
+		if fd.IsMap() {
+			if conformance64Bit(fd.MapKey()) {
+				return "64-bit integers are unsupported"
+			}
+			fd = fd.MapValue()
+		}
+		if conformance64Bit(fd) {
+			return "64-bit integers are unsupported"
+		}
+		switch fd.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			sub := fd.Message()
+			if strings.HasPrefix(string(sub.FullName()), "google.protobuf.") {
+				if !conformanceWellKnown[sub.FullName()] {
+					return "well-known type " + string(sub.FullName()) + " is unsupported"
+				}
+				continue
+			}
+			if many && seen[sub.FullName()] {
+				continue
+			}
+			if reason := conformanceUnsupported(sub, seen); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// Elm's Int can't hold 64-bit integers
+func conformance64Bit(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+		protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+		return true
+	}
+	return false
+}
+
+// Writes Elm literals equivalent to Protobuf values. Tracks the modules referenced
+type conformanceLiterals struct {
+	m       *Module
+	imports map[string]bool
+}
+
+func (lit *conformanceLiterals) ref(r *ElmRef) string {
+	if r.Module != "" {
+		lit.imports[r.Module] = true
+	}
+	return r.String()
+}
+
+// A record literal
+func (lit *conformanceLiterals) message(msg protoreflect.Message) string {
+	md := msg.Descriptor()
+	var fields []string
+	oneofsSeen := make(map[protoreflect.FullName]bool)
+	fds := md.Fields()
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		od := fd.ContainingOneof()
+		switch {
+		case od != nil && od.IsSynthetic(): // Optional
+			value := "Nothing"
+			if msg.Has(fd) {
+				value = "(Just " + lit.field(fd, msg.Get(fd)) + ")"
+			}
+			fields = append(fields, protoIdentToElmValue(string(fd.Name()))+" = "+value)
+
+		case od != nil:
+			if oneofsSeen[od.FullName()] {
+				continue
+			}
+			oneofsSeen[od.FullName()] = true
+			value := "Nothing"
+			if which := msg.WhichOneof(od); which != nil {
+				variant := lit.m.NewElmType(which.ParentFile(), which).ElmRef
+				value = fmt.Sprintf("(Just (%s %s))", lit.ref(variant), lit.field(which, msg.Get(which)))
+			}
+			fields = append(fields, protoIdentToElmValue(string(od.Name()))+" = "+value)
+
+		default:
+			fields = append(fields, protoIdentToElmValue(string(fd.Name()))+" = "+lit.fieldOrZero(msg, fd))
+		}
+	}
+	if len(fields) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+// A regular field's value including lists and maps. Unset messages take their zero value
+func (lit *conformanceLiterals) fieldOrZero(msg protoreflect.Message, fd protoreflect.FieldDescriptor) string {
+	v := msg.Get(fd)
+	switch {
+	case fd.IsMap():
+		mp := v.Map()
+		var keys []protoreflect.MapKey
+		mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		// Order doesn't matter to Dict but keeps output stable. Association lists are sorted by key which works for bools ("false" < "true")
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		var entries []string
+		for _, k := range keys {
+			entries = append(entries, fmt.Sprintf("( %s, %s )",
+				lit.field(fd.MapKey(), k.Value()), lit.field(fd.MapValue(), mp.Get(k))))
+		}
+		if toKey, _ := mapKeyConv(fd); toKey != "" {
+			return "[" + strings.Join(entries, ", ") + "]"
+		}
+		lit.imports["Dict"] = true
+		return "(Dict.fromList [" + strings.Join(entries, ", ") + "])"
+
+	case fd.IsList():
+		list := v.List()
+		var elems []string
+		for i := 0; i < list.Len(); i++ {
+			elems = append(elems, lit.field(fd, list.Get(i)))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+
+	case fd.Message() != nil && !msg.Has(fd):
+		sub := fd.Message()
+		if conformanceWellKnown[sub.FullName()] && sub.FullName() != "google.protobuf.Timestamp" {
+			return "Nothing" // Wrapper
+		}
+		return lit.ref(lit.m.NewElmType(sub.ParentFile(), sub).Zero)
+	}
+	return lit.field(fd, v)
+}
+
+// A singular value. Negative numbers are wrapped in parentheses
+func (lit *conformanceLiterals) field(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	signed := func(s string) string {
+		if strings.HasPrefix(s, "-") {
+			return "(" + s + ")"
+		}
+		return s
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if v.Bool() {
+			return "True"
+		}
+		return "False"
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return signed(strconv.FormatInt(v.Int(), 10))
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return strconv.FormatUint(v.Uint(), 10)
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return signed(strconv.FormatFloat(v.Float(), 'f', -1, 64))
+
+	case protoreflect.StringKind:
+		return elmString(v.String())
+
+	case protoreflect.BytesKind:
+		lit.imports[importElmer] = true
+		return fmt.Sprintf(`(%s.fromBase64 "%s" |> Maybe.withDefault %s.emptyBytes)`,
+			importElmer, base64.StdEncoding.EncodeToString(v.Bytes()), importElmer)
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		vd := ed.Values().ByNumber(v.Enum())
+		if vd == nil { // Unrecognised takes the default
+			vd = ed.Values().Get(0)
+		}
+		return lit.ref(lit.m.NewElmType(vd.ParentFile(), vd).ElmRef)
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		sub := v.Message()
+		switch name := sub.Descriptor().FullName(); {
+		case name == "google.protobuf.Timestamp":
+			lit.imports["Time"] = true
+			seconds := sub.Get(sub.Descriptor().Fields().ByName("seconds")).Int()
+			nanos := sub.Get(sub.Descriptor().Fields().ByName("nanos")).Int()
+			return fmt.Sprintf("(Time.millisToPosix %d)", seconds*1000+nanos/1000000)
+		case conformanceWellKnown[name]: // Wrapper
+			inner := sub.Descriptor().Fields().ByName("value")
+			return "(Just " + lit.field(inner, sub.Get(inner)) + ")"
+		}
+		return lit.message(sub)
+	}
+
+	// Unreachable: conformanceUnsupported skips these
+	lit.m.errorf(fd, "conformance: no literal for %s", fd.Kind())
+	return ""
+}
+
+// Quotes a string as an Elm literal
+func elmString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u{%04X}`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,351 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Generates an RPC explorer: a form for every record, union and oneof plus an Elm program that calls any (non-streaming) RPC method through the Twirp client and shows the response. Depends on the Twirp and View modules of the same package. The Twirp module must use the default options: metadata and effects change the client's signatures.
+func GenerateExplorer(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	var rpcs []*RPC
+	for _, s := range m.Services {
+		for _, rpc := range s.Methods {
+			if !rpc.IsStreaming() {
+				rpcs = append(rpcs, rpc)
+			}
+		}
+	}
+
+	g.P("module ", m.Name, " exposing (..)")
+	gFP("{-| Protobuf library for editing structures and calling RPC methods found in package `" + m.ProtoPackage + "`. Each form produces an edited copy of its value. Use `main` as a standalone program or embed `init`, `update` and `view` in an application. Calls the Twirp module generated with default options (without `metadata` or `effects`). This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	g.P("import Html exposing (Html)")
+	if len(rpcs) > 0 {
+		g.P("import Browser")
+		g.P("import Http")
+		gFP("import %sTwirp", rpcs[0].ID.Module)
+	}
+	printImports(g, m, "Explorer", "View")
+
+	// Union forms
+	for _, u := range m.Unions {
+		t := u.Type
+		gFP("%s : %s -> Html %s", t.Form.ID, t, t)
+		gFP("%s =", t.Form.ID)
+		gFP("    %s.enum %s %s", importElmerExplorer,
+			&ElmRef{t.Module, "from" + t.ID}, &ElmRef{t.Module, "valuesOf" + t.ID})
+	}
+
+	// Oneof forms
+	for _, o := range m.Oneofs {
+		if o.IsSynthetic { // Optional fields use Protobuf.ElmerExplorer.maybe instead
+			continue
+		}
+		t := o.Type
+		gFP("%s : Maybe %s -> Html (Maybe %s)", t.Form.ID, t, t)
+		gFP("%s v =", t.Form.ID)
+		g.P("    let")
+		g.P("        choices =")
+		printElmList(g, "            ", len(o.Variants), func(i int) string {
+			v := o.Variants[i]
+			return fmt.Sprintf("( \"%s\", %s %s )", v.Field.Desc.Name(), v.ID, fieldFormZero(m, v.Field.Desc))
+		})
+		g.P("    in")
+		g.P("    case v of")
+		g.P("        Nothing ->")
+		gFP("            %s.choose choices Nothing (Html.text \"\")", importElmerExplorer)
+		for _, v := range o.Variants {
+			fd := v.Field.Desc
+			gFP("        Just (%s x) ->", v.ID)
+			gFP("            %s.choose choices (Just \"%s\")", importElmerExplorer, fd.Name())
+			gFP("                (%s)", fieldForm(fd, "(Just << "+v.ID.String()+")", fieldFormValue(m, fd, "x")))
+		}
+	}
+
+	// Record forms
+	for _, r := range m.Records {
+		t := r.Type
+		gFP("%s : %s -> Html %s", t.Form.ID, t, t)
+		if len(r.Fields) == 0 {
+			gFP("%s _ =", t.Form.ID)
+			gFP("    %s.record \"%s\" []", importElmerExplorer, r.Desc.FullName())
+			continue
+		}
+		gFP("%s v =", t.Form.ID)
+		gFP("    %s.record \"%s\"", importElmerExplorer, r.Desc.FullName())
+		printElmList(g, "        ", len(r.Fields), func(i int) string {
+			f := r.Fields[i]
+			value := "v." + f.Label
+			set := fmt.Sprintf("(\\x -> { v | %s = x })", f.Label)
+			switch {
+			case f.Oneof == nil:
+				return fieldForm(f.Desc, set, fieldFormValue(m, f.Desc, value))
+			case f.Oneof.IsSynthetic:
+				return fieldForm(f.Desc, set, fmt.Sprintf("(%s.maybe %s %s %s)",
+					importElmerExplorer, fieldFormZero(m, f.Desc), fieldFormKind(m, f.Desc), value))
+			default:
+				name := f.Oneof.Variants[0].Field.Desc.ContainingOneof().Name()
+				return fmt.Sprintf("%s.oneof \"%s\" %s (%s %s)", importElmerExplorer, name, set, f.Oneof.Type.Form, value)
+			}
+		})
+	}
+
+	if len(rpcs) > 0 {
+		printExplorer(g, rpcs)
+	}
+	return len(rpcs) > 0 || len(m.Unions) > 0 || len(m.Records) > 0
+}
+
+// Prints an Elm program for calling RPCs: a method picker, the request's form and the last response
+func printExplorer(g *protogen.GeneratedFile, rpcs []*RPC) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	variant := func(rpc *RPC) string { return rpc.IDWithPrefix("") }
+	response := func(rpc *RPC) string { return "Response" + rpc.IDWithPrefix("") }
+	input := func(rpc *RPC) string { return "input" + rpc.IDWithPrefix("") }
+	var inputs []*RPC
+	for _, rpc := range rpcs {
+		if !rpc.InEmpty {
+			inputs = append(inputs, rpc)
+		}
+	}
+	printRecord := func(indent string, value func(*RPC) string) {
+		if len(inputs) == 0 {
+			g.P(indent, "{}")
+			return
+		}
+		for i, rpc := range inputs {
+			sep := ","
+			if i == 0 {
+				sep = "{"
+			}
+			g.P(indent, sep, " ", input(rpc), value(rpc))
+		}
+		g.P(indent, "}")
+	}
+
+	g.P("{-| RPC methods that can be called. Streaming methods are skipped. -}")
+	g.P("type Method")
+	for i, rpc := range rpcs {
+		prefix := "="
+		if i > 0 {
+			prefix = "|"
+		}
+		gFP("    %s %s", prefix, variant(rpc))
+	}
+
+	g.P("methods : List ( String, Method )")
+	g.P("methods =")
+	printElmList(g, "    ", len(rpcs), func(i int) string {
+		return fmt.Sprintf("( \"%s/%s\", %s )", rpcs[i].Service, rpcs[i].Method, variant(rpcs[i]))
+	})
+
+	g.P("{-| Request being edited for each method. -}")
+	g.P("type alias Inputs =")
+	printRecord("    ", func(rpc *RPC) string { return " : " + rpc.In.String() })
+
+	g.P("{-| A successful response from any method. -}")
+	g.P("type Response")
+	for i, rpc := range rpcs {
+		prefix := "="
+		if i > 0 {
+			prefix = "|"
+		}
+		gFP("    %s %s %s", prefix, response(rpc), rpc.OutType())
+	}
+
+	g.P("type alias Model =")
+	g.P("    { api : String")
+	g.P("    , method : Method")
+	g.P("    , inputs : Inputs")
+	g.P("    , loading : Bool")
+	g.P("    , response : Maybe (Result Http.Error Response)")
+	g.P("    }")
+
+	g.P("type Msg")
+	g.P("    = SetApi String")
+	g.P("    | Pick Method")
+	g.P("    | Edit Inputs")
+	g.P("    | Send")
+	g.P("    | Got (Result Http.Error Response)")
+
+	g.P("{-| Starts with empty requests for a Twirp server at the given URL prefix. -}")
+	g.P("init : String -> ( Model, Cmd Msg )")
+	g.P("init api =")
+	g.P("    ( { api = api")
+	gFP("      , method = %s", variant(rpcs[0]))
+	g.P("      , inputs =")
+	printRecord("            ", func(rpc *RPC) string { return " = " + rpc.In.Zero.String() })
+	g.P("      , loading = False")
+	g.P("      , response = Nothing")
+	g.P("      }")
+	g.P("    , Cmd.none")
+	g.P("    )")
+
+	g.P("update : Msg -> Model -> ( Model, Cmd Msg )")
+	g.P("update msg model =")
+	g.P("    case msg of")
+	g.P("        SetApi api ->")
+	g.P("            ( { model | api = api }, Cmd.none )")
+	g.P("        Pick method ->")
+	g.P("            ( { model | method = method, response = Nothing }, Cmd.none )")
+	g.P("        Edit inputs ->")
+	g.P("            ( { model | inputs = inputs }, Cmd.none )")
+	g.P("        Send ->")
+	g.P("            ( { model | loading = True, response = Nothing }, send model )")
+	g.P("        Got response ->")
+	g.P("            ( { model | loading = False, response = Just response }, Cmd.none )")
+
+	g.P("{-| Calls the picked method with its request. -}")
+	g.P("send : Model -> Cmd Msg")
+	g.P("send model =")
+	g.P("    case model.method of")
+	for _, rpc := range rpcs {
+		data := ""
+		if !rpc.InEmpty {
+			data = " model.inputs." + input(rpc)
+		}
+		gFP("        %s ->", variant(rpc))
+		gFP("            %s (Result.map %s >> Got) model.api%s",
+			&ElmRef{rpc.ID.Module + "Twirp", rpc.ID.ID}, response(rpc), data)
+	}
+
+	g.P("{-| Form for the picked method's request. -}")
+	g.P("editInputs : Method -> Inputs -> Html Inputs")
+	g.P("editInputs method inputs =")
+	g.P("    case method of")
+	for _, rpc := range rpcs {
+		gFP("        %s ->", variant(rpc))
+		if rpc.InEmpty {
+			gFP("            %s.record \"%s\" []", importElmerExplorer, rpc.Desc.Input().FullName())
+			continue
+		}
+		gFP("            Html.map (\\x -> { inputs | %s = x }) (%s inputs.%s)", input(rpc), rpc.In.Form, input(rpc))
+	}
+
+	g.P("viewResponse : Response -> Html msg")
+	g.P("viewResponse response =")
+	g.P("    case response of")
+	for _, rpc := range rpcs {
+		if rpc.OutEmpty {
+			gFP("        %s _ ->", response(rpc))
+			gFP("            %s %s", rpc.Out.View, rpc.Out.Zero)
+			continue
+		}
+		gFP("        %s x ->", response(rpc))
+		gFP("            %s x", rpc.Out.View)
+	}
+
+	g.P("view : Model -> Html Msg")
+	g.P("view model =")
+	gFP("    %s.page", importElmerExplorer)
+	g.P("        { api = model.api")
+	g.P("        , onApi = SetApi")
+	gFP("        , methods = Html.map Pick (%s.select methods model.method)", importElmerExplorer)
+	g.P("        , form = Html.map Edit (editInputs model.method model.inputs)")
+	g.P("        , onSend = Send")
+	g.P("        , loading = model.loading")
+	g.P("        , response = Maybe.map (Result.map viewResponse) model.response")
+	g.P("        }")
+
+	g.P("{-| A standalone explorer calling a Twirp server on the same host. -}")
+	g.P("main : Program () Model Msg")
+	g.P("main =")
+	g.P("    Browser.element")
+	g.P(`        { init = \_ -> init "/twirp"`)
+	g.P("        , update = update")
+	g.P("        , view = view")
+	g.P(`        , subscriptions = \_ -> Sub.none`)
+	g.P("        }")
+}
+
+// Labels a field's form with its Protobuf name and number
+func fieldForm(fd protoreflect.FieldDescriptor, set, form string) string {
+	return fmt.Sprintf("%s.field \"%s\" %d %s %s", importElmerExplorer, fd.Name(), fd.Number(), set, form)
+}
+
+// A field's form including lists and maps
+func fieldFormValue(m *Module, fd protoreflect.FieldDescriptor, value string) string {
+	if fd.IsMap() {
+		key, val := fd.MapKey(), fd.MapValue()
+		fn := "dict"
+		if toKey, _ := mapKeyConv(fd); toKey != "" { // Association list
+			fn = "assoc"
+		}
+		return fmt.Sprintf("(%s.%s %s %s %s %s %s)", importElmerExplorer, fn,
+			fieldFormZero(m, key), fieldFormZero(m, val), fieldFormKind(m, key), fieldFormKind(m, val), value)
+	} else if fd.IsList() {
+		return fmt.Sprintf("(%s.list %s %s %s)", importElmerExplorer, fieldFormZero(m, fd), fieldFormKind(m, fd), value)
+	}
+	return fmt.Sprintf("(%s %s)", fieldFormKind(m, fd), value)
+}
+
+// Zero value of a single item, ignoring lists and maps
+func fieldFormZero(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "False"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "0.0"
+	case protoreflect.StringKind:
+		return `""`
+	case protoreflect.BytesKind:
+		return importElmer + ".emptyBytes"
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		return m.NewElmType(ed.ParentFile(), ed).Zero.String()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		return m.NewElmType(md.ParentFile(), md).Zero.String()
+	}
+	// Integers. Others are reported by fieldFormKind
+	return "0"
+}
+
+func fieldFormKind(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return importElmerExplorer + ".bool"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Fixed32Kind:
+		return importElmerExplorer + ".int"
+
+	// Unsupported by Elm / JS
+	//case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+	//	protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return importElmerExplorer + ".float"
+
+	case protoreflect.StringKind:
+		return importElmerExplorer + ".string"
+	case protoreflect.BytesKind:
+		return importElmerExplorer + ".bytes"
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		return m.NewElmType(ed.ParentFile(), ed).Form.String()
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		return m.NewElmType(md.ParentFile(), md).Form.String()
+	}
+
+	m.unsupportedKind(fd)
+	return "(Debug.todo \"unsupported\")"
+}
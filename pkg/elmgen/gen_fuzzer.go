@@ -9,13 +9,24 @@ package elmgen
 
 import (
 	"fmt"
-	"log"
 
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
+// Options for generating fuzz tests
+type FuzzOptions struct {
+	Previous *protoregistry.Files // Optional. An older version of the schema to test compatibility with
+}
+
+// Generates fuzz tests with default options
 func GenerateFuzzTests(m *Module, g *protogen.GeneratedFile) bool {
+	return FuzzOptions{}.Generate(m, g)
+}
+
+// Generates fuzzers for every type and tests that run them through an encoder then decoder. With a previous schema, messages found in both versions are also tested across versions.
+func (opts FuzzOptions) Generate(m *Module, g *protogen.GeneratedFile) bool {
 	gFP := func(formatter string, args ...interface{}) {
 		g.P(fmt.Sprintf(formatter, args...))
 	}
@@ -27,7 +38,7 @@ func GenerateFuzzTests(m *Module, g *protogen.GeneratedFile) bool {
 	g.P("import Expect")
 	g.P("import Fuzz exposing (Fuzzer)")
 	g.P("import Test exposing (Test, fuzz, test)")
-	printImports(g, m, false)
+	printImports(g, m, "Tests")
 
 	// Union fuzzers
 	for _, u := range m.Unions {
@@ -113,6 +124,341 @@ func GenerateFuzzTests(m *Module, g *protogen.GeneratedFile) bool {
 		gFP("        ]")
 	}
 
+	// Merging should agree with decoding concatenated encodings
+	for _, r := range m.Records {
+		t := r.Type
+		gFP("mergeTest%s : Test", t.ID)
+		gFP("mergeTest%s =", t.ID)
+		gFP(`    fuzz (Fuzz.tuple ( %s, %s )) "merge %s like concatenated encodings"`, t.Fuzzer.ID, t.Fuzzer.ID, t.ID)
+		gFP("        (%s.mergeTest %s %s %s %s)", importElmerTests, t.Decoder, t.Encoder,
+			&ElmRef{t.Module, "merge" + t.ID}, &ElmRef{t.Module, "equal" + t.ID})
+	}
+
+	// Patching with a diff should reproduce the new value
+	for _, r := range m.Records {
+		t := r.Type
+		gFP("diffTest%s : Test", t.ID)
+		gFP("diffTest%s =", t.ID)
+		gFP(`    fuzz (Fuzz.tuple ( %s, %s )) "patch %s with its diff"`, t.Fuzzer.ID, t.Fuzzer.ID, t.ID)
+		gFP("        (%s.diffTest %s %s %s)", importElmerTests,
+			&ElmRef{t.Module, "diff" + t.ID}, &ElmRef{t.Module, "patch" + t.ID}, &ElmRef{t.Module, "equal" + t.ID})
+	}
+
+	if opts.Previous != nil {
+		evo := newEvolution(m, opts.Previous)
+		for _, u := range m.Unions {
+			if prev, ok := evo.unions[u.Desc.FullName()]; ok {
+				printEvolutionUnion(g, u, prev)
+			}
+		}
+		for _, r := range m.Records {
+			if prev, ok := evo.records[r.Desc.FullName()]; ok {
+				evo.printTest(g, r, prev)
+			}
+		}
+	}
+
+	return true
+}
+
+// The previous version of a module's types. Records and unions in the module found in both versions are keyed by their full name
+type evolution struct {
+	m       *Module
+	records map[protoreflect.FullName]protoreflect.MessageDescriptor
+	unions  map[protoreflect.FullName]protoreflect.EnumDescriptor // Only unions that gained values
+	ids     map[protoreflect.FullName]string                      // Elm IDs of records and unions above
+}
+
+func newEvolution(m *Module, previous *protoregistry.Files) *evolution {
+	evo := &evolution{m, make(map[protoreflect.FullName]protoreflect.MessageDescriptor),
+		make(map[protoreflect.FullName]protoreflect.EnumDescriptor), make(map[protoreflect.FullName]string)}
+	for _, r := range m.Records {
+		desc, err := previous.FindDescriptorByName(r.Desc.FullName())
+		if prev, ok := desc.(protoreflect.MessageDescriptor); err == nil && ok {
+			evo.records[r.Desc.FullName()] = prev
+			evo.ids[r.Desc.FullName()] = r.Type.ID
+		}
+	}
+	for _, u := range m.Unions {
+		desc, err := previous.FindDescriptorByName(u.Desc.FullName())
+		if prev, ok := desc.(protoreflect.EnumDescriptor); err == nil && ok {
+			for _, v := range u.Variants {
+				if prev.Values().ByNumber(v.Number) == nil {
+					evo.unions[u.Desc.FullName()] = prev
+					evo.ids[u.Desc.FullName()] = u.Type.ID
+					break
+				}
+			}
+		}
+	}
+	return evo
+}
+
+// Prints a union's conversion to its previous version. Values the previous version doesn't know are read as the default
+func printEvolutionUnion(g *protogen.GeneratedFile, u *Union, prev protoreflect.EnumDescriptor) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	gFP("evolutionShared%s : %s -> %s", u.Type.ID, u.Type, u.Type)
+	gFP("evolutionShared%s v =", u.Type.ID)
+	gFP("    case v of")
+	for _, v := range u.Variants {
+		if prev.Values().ByNumber(v.Number) == nil {
+			gFP("        %s ->", v.ID)
+			gFP("            %s", u.Default().ID)
+		}
+	}
+	gFP("        _ ->")
+	gFP("            v")
+}
+
+// Prints a test of a record against its previous version. The previous version is described by an encoder writing the fields both versions share plus fixed values for fields only the previous version has, and a decoder reading the shared fields. Data must survive being written by one version and read by the other, minus anything the previous version doesn't know. Nested records and unions from the same package take their previous shape too
+func (evo *evolution) printTest(g *protogen.GeneratedFile, r *Record, prev protoreflect.MessageDescriptor) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	m := evo.m
+	id := r.Type.ID
+	shared := previousFields(m, r.Fields, prev)
+	var extra []string
+	fields := prev.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		old := fields.Get(i)
+		if r.Desc.Fields().ByNumber(old.Number()) == nil {
+			if enc := evolutionUnknown(old); enc != "" {
+				extra = append(extra, fmt.Sprintf("( %d, %s )", old.Number(), enc))
+			}
+		}
+	}
+	printRecordDecoder(g, m, "evolutionDecode"+id, r.Type, shared)
+	printRecordEncoder(g, m, "evolutionEncode"+id, r.Type, shared, evo.fieldEncoder, extra...)
+
+	gFP("evolutionShared%s : %s -> %s", id, r.Type, r.Type)
+	if len(shared) == 0 {
+		gFP("evolutionShared%s _ =", id)
+		gFP("    %s", r.Type.Zero)
+	} else {
+		gFP("evolutionShared%s v =", id)
+		gFP("    let")
+		gFP("        zero = %s", r.Type.Zero)
+		gFP("    in")
+		for i, f := range shared {
+			prefix := ","
+			if i == 0 {
+				prefix = "{ zero |"
+			}
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				evo.printOneofConv(g, prefix, f, prev)
+			} else if conv := evo.fieldConv(f); conv != "" {
+				gFP("    %s %s = %s v.%s", prefix, f.Label, conv, f.Label)
+			} else {
+				gFP("    %s %s = v.%s", prefix, f.Label, f.Label)
+			}
+		}
+		gFP("    }")
+	}
+
+	equal := &ElmRef{r.Type.Module, "equal" + id}
+	gFP("evolutionTest%s : Test", id)
+	gFP("evolutionTest%s =", id)
+	gFP(`    Test.describe "schema evolution of %s"`, id)
+	gFP(`        [ fuzz %s "previous to current" <|`, r.Type.Fuzzer.ID)
+	gFP(`            \v -> PE.encode (evolutionEncode%s (evolutionShared%s v)) |> PD.decode %s |> Maybe.map (%s (evolutionShared%s v)) |> Expect.equal (Just True)`,
+		id, id, r.Type.Decoder, equal, id)
+	gFP(`        , fuzz %s "current to previous" <|`, r.Type.Fuzzer.ID)
+	gFP(`            \v -> PE.encode (%s v) |> PD.decode (PD.map evolutionShared%s evolutionDecode%s) |> Maybe.map (%s (evolutionShared%s v)) |> Expect.equal (Just True)`,
+		r.Type.Encoder, id, id, equal, id)
+	gFP("        ]")
+}
+
+// Encodes a field's kind in its previous shape
+func (evo *evolution) fieldEncoder(m *Module, fd protoreflect.FieldDescriptor) string {
+	if md := fd.Message(); md != nil {
+		if id, ok := evo.ids[md.FullName()]; ok {
+			return "evolutionEncode" + id
+		}
+	}
+	return fieldEncoder(m, fd)
+}
+
+// Returns an Elm function converting a field's kind to its previous shape or an empty string if the shape is unchanged
+func (evo *evolution) kindConv(fd protoreflect.FieldDescriptor) string {
+	var name protoreflect.FullName
+	if md := fd.Message(); md != nil {
+		name = md.FullName()
+	} else if ed := fd.Enum(); ed != nil {
+		name = ed.FullName()
+	}
+	if id, ok := evo.ids[name]; ok {
+		return "evolutionShared" + id
+	}
+	return ""
+}
+
+// Returns an Elm function converting a regular or optional field to its previous shape or an empty string if the shape is unchanged
+func (evo *evolution) fieldConv(f *Field) string {
+	fd := f.Desc
+	if fd.IsMap() {
+		conv := evo.kindConv(fd.MapValue())
+		if conv == "" {
+			return ""
+		} else if toKey, _ := mapKeyConv(fd); toKey != "" {
+			return "(List.map (Tuple.mapSecond " + conv + "))"
+		}
+		return "(Dict.map (\\_ -> " + conv + "))"
+	}
+	conv := evo.kindConv(fd)
+	switch {
+	case conv == "":
+		return ""
+	case fd.IsList():
+		return "(List.map " + conv + ")"
+	case f.Oneof != nil: // Optional
+		return "(Maybe.map " + conv + ")"
+	}
+	return conv
+}
+
+// Prints a oneof's conversion to its previous shape as a record field. Variants the previous version doesn't know are dropped
+func (evo *evolution) printOneofConv(g *protogen.GeneratedFile, prefix string, f *Field, prev protoreflect.MessageDescriptor) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	var changed bool
+	for _, v := range f.Oneof.Variants {
+		fd := v.Field.Desc
+		changed = changed || prev.Fields().ByNumber(fd.Number()) == nil || evo.kindConv(fd) != ""
+	}
+	if !changed {
+		gFP("    %s %s = v.%s", prefix, f.Label, f.Label)
+		return
+	}
+	gFP("    %s %s =", prefix, f.Label)
+	gFP("        Maybe.andThen")
+	gFP("            (\\o ->")
+	gFP("                case o of")
+	for _, v := range f.Oneof.Variants {
+		fd := v.Field.Desc
+		if prev.Fields().ByNumber(fd.Number()) == nil {
+			gFP("                    %s _ ->", v.ID)
+			gFP("                        Nothing")
+		} else if conv := evo.kindConv(fd); conv != "" {
+			gFP("                    %s x ->", v.ID)
+			gFP("                        Just (%s (%s x))", v.ID, conv)
+		} else {
+			gFP("                    %s x ->", v.ID)
+			gFP("                        Just (%s x)", v.ID)
+		}
+	}
+	gFP("            )")
+	gFP("            v.%s", f.Label)
+}
+
+// Filters a record's fields to those a previous version of its message shares. Reused field numbers must keep their type and presence, breaking changes are errors. Oneofs are shared if any of their fields are, which must all come from a single previous oneof
+func previousFields(m *Module, fields []*Field, prev protoreflect.MessageDescriptor) (shared []*Field) {
+	compatible := func(fd, old protoreflect.FieldDescriptor) bool {
+		if !sameFieldType(fd, old) {
+			m.errorf(fd, "field %d changed type since the previous schema", fd.Number())
+			return false
+		} else if !sameFieldPresence(fd, old) {
+			m.errorf(fd, "field %d changed presence since the previous schema", fd.Number())
+			return false
+		}
+		return true
+	}
+	for _, f := range fields {
+		if f.Oneof != nil && !f.Oneof.IsSynthetic {
+			var prevOneof protoreflect.OneofDescriptor
+			ok := true
+			for _, v := range f.Oneof.Variants {
+				fd := v.Field.Desc
+				old := prev.Fields().ByNumber(fd.Number())
+				switch {
+				case old == nil: // New variant
+				case !compatible(fd, old):
+					ok = false
+				case prevOneof != nil && prevOneof != old.ContainingOneof():
+					m.errorf(fd, "field %d moved oneof since the previous schema", fd.Number())
+					ok = false
+				default:
+					prevOneof = old.ContainingOneof()
+				}
+			}
+			if ok && prevOneof != nil {
+				shared = append(shared, f)
+			}
+			continue
+		}
+
+		old := prev.Fields().ByNumber(f.Desc.Number())
+		if old != nil && compatible(f.Desc, old) {
+			shared = append(shared, f)
+		}
+	}
+	return
+}
+
+// Returns an Elm encoder writing a fixed value for a field only the previous version has, checking it's skipped as unknown. Empty if Elm can't write it (groups)
+func evolutionUnknown(fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		return fmt.Sprintf("PE.message [ ( 1, %s ), ( 2, %s ) ]",
+			evolutionUnknown(fd.MapKey()), evolutionUnknown(fd.MapValue()))
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "PE.bool True"
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.EnumKind:
+		return "PE.int32 1"
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "PE.sint32 1"
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return "PE.uint32 1"
+	case protoreflect.Sfixed32Kind:
+		return "PE.sfixed32 1"
+	case protoreflect.Fixed32Kind:
+		return "PE.fixed32 1"
+	case protoreflect.FloatKind:
+		return "PE.float 1.5"
+	case protoreflect.DoubleKind, protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind: // Same wire type
+		return "PE.double 1.5"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return `PE.string "previous"`
+	case protoreflect.MessageKind:
+		return "PE.message [ ( 1, PE.int32 1 ) ]"
+	}
+	return ""
+}
+
+// Returns true if two fields (from different versions) are both regular, optional or in a oneof
+func sameFieldPresence(fd, old protoreflect.FieldDescriptor) bool {
+	presence := func(fd protoreflect.FieldDescriptor) int {
+		od := fd.ContainingOneof()
+		switch {
+		case od == nil:
+			return 0
+		case od.IsSynthetic():
+			return 1
+		}
+		return 2
+	}
+	return presence(fd) == presence(old)
+}
+
+// Returns true if two fields (from different versions) hold the same type. Old may be nil
+func sameFieldType(fd, old protoreflect.FieldDescriptor) bool {
+	if old == nil || fd.Kind() != old.Kind() || fd.IsList() != old.IsList() || fd.IsMap() != old.IsMap() {
+		return false
+	}
+	if fd.IsMap() {
+		return sameFieldType(fd.MapKey(), old.MapKey()) &&
+			sameFieldType(fd.MapValue(), old.MapValue())
+	}
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return fd.Enum().FullName() == old.Enum().FullName()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return fd.Message().FullName() == old.Message().FullName()
+	}
 	return true
 }
 
@@ -120,6 +466,9 @@ func fieldFuzzer(m *Module, fd protoreflect.FieldDescriptor) string {
 	if fd.IsMap() {
 		key := fieldFuzzer(m, fd.MapKey())
 		val := fieldFuzzer(m, fd.MapValue())
+		if toKey, fromKey := mapKeyConv(fd); toKey != "" { // Sorted association list
+			return "(Fuzz.map (" + importElmer + ".assocToDict " + toKey + " >> " + importElmer + ".dictToAssoc " + fromKey + ") (Fuzz.list (Fuzz.tuple (" + key + ", " + val + "))))"
+		}
 		return "(Fuzz.map Dict.fromList (Fuzz.list (Fuzz.tuple (" + key + ", " + val + "))))"
 	} else if fd.IsList() {
 		return "(Fuzz.list " + fieldFuzzerKind(m, fd) + ")"
@@ -162,6 +511,6 @@ func fieldFuzzerKind(m *Module, fd protoreflect.FieldDescriptor) string {
 		return m.NewElmType(md.ParentFile(), md).Fuzzer.String()
 	}
 
-	log.Panicf("kindFuzzer: unknown protoreflect.Kind: %s", fd.Kind())
-	return ""
+	m.unsupportedKind(fd)
+	return "Fuzz.invalid \"unsupported\""
 }
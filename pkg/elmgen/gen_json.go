@@ -0,0 +1,319 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Well-known types that have a JSON codec in Protobuf.ElmerJson. The rest (e.g., Any) need a type registry
+var jsonWellKnown = map[string]bool{
+	"BoolValue": true, "BytesValue": true, "DoubleValue": true,
+	"FloatValue": true, "Int32Value": true, "Int64Value": true,
+	"StringValue": true, "UInt32Value": true, "UInt64Value": true,
+	"Duration": true, "Empty": true, "FieldMask": true, "ListValue": true,
+	"NullValue": true, "Struct": true, "Timestamp": true, "Value": true,
+}
+
+// Generates Elm JSON decoders and encoders following Protobuf's JSON mapping (as used by REST gateways). Types come from the codec module.
+func GenerateJSON(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	gFP("module %s exposing (..)", m.Name)
+	gFP("{-| Protobuf library for decoding and encoding structures found in package `" + m.ProtoPackage + "` as JSON. Follows Protobuf's JSON mapping. This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	g.P("import Json.Decode as JD")
+	g.P("import Json.Encode as JE")
+	printImports(g, m, "Json")
+
+	// Records reaching a well-known type without a JSON codec are skipped
+	skipped := make(map[*Record]bool)
+	for _, r := range m.Records {
+		if name := jsonUnsupported(r.Desc, make(map[protoreflect.FullName]bool)); name != "" {
+			gFP("-- Skipped %s: well-known type %s has no JSON codec", r.Desc.FullName(), name)
+			skipped[r] = true
+		}
+	}
+
+	// Record decoders
+	for _, r := range m.Records {
+		if skipped[r] {
+			continue
+		}
+		gFP("%s : JD.Decoder %s", r.Type.JSONDecoder, r.Type)
+		gFP("%s =", r.Type.JSONDecoder)
+		gFP("    JD.succeed %s", r.Type)
+		for _, f := range r.Fields {
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				gFP("        |> %s.andMap", importElmerJSON)
+				g.P("            (JD.oneOf")
+				for j, v := range f.Oneof.Variants {
+					prefix := "                ["
+					if j != 0 {
+						prefix = "                ,"
+					}
+					fd := v.Field.Desc
+					gFP("%s JD.map (Just << %s) (%s.present \"%s\" \"%s\" %s)",
+						prefix, v.ID, importElmerJSON,
+						fd.JSONName(), fd.Name(), fieldJSONDecoder(m, fd))
+				}
+				g.P("                , JD.succeed Nothing")
+				g.P("                ])")
+				continue
+			}
+			fd := f.Desc
+			if f.Oneof != nil { // Optional
+				gFP("        |> %s.optional \"%s\" \"%s\" %s",
+					importElmerJSON, fd.JSONName(), fd.Name(), fieldJSONDecoder(m, fd))
+			} else {
+				gFP("        |> %s.required \"%s\" \"%s\" %s %s",
+					importElmerJSON, fd.JSONName(), fd.Name(),
+					fieldJSONDecoder(m, fd), fieldZero(m, fd))
+			}
+		}
+	}
+
+	// Union decoders: accept names or numbers
+	for _, u := range m.Unions {
+		t := u.Type
+		gFP("%s : JD.Decoder %s", t.JSONDecoder, t)
+		gFP("%s =", t.JSONDecoder)
+		g.P("    let")
+		g.P("        conv v =")
+		g.P("            case v of")
+		for _, v := range u.Variants {
+			g.P("                ", v.Number, " ->")
+			g.P("                    ", v.ID)
+		}
+		g.P("                _ ->")
+		g.P("                    ", u.Default().ID)
+		g.P("    in")
+		gFP("    JD.oneOf [ JD.map %s JD.string, JD.map conv JD.int ]",
+			&ElmRef{t.Module, "to" + t.ID})
+	}
+
+	// Record encoders
+	for _, r := range m.Records {
+		if skipped[r] {
+			continue
+		}
+		param := "v"
+		if len(r.Fields) == 0 {
+			param = "_"
+		}
+		gFP("%s : %s -> JE.Value", r.Type.JSONEncoder, r.Type)
+		gFP("%s %s =", r.Type.JSONEncoder, param)
+		oneofs := r.Oneofs()
+		var unions []*Field // Non-optional oneofs
+		for _, f := range oneofs {
+			if !f.Oneof.IsSynthetic { // Optional uses encodeOptional instead
+				unions = append(unions, f)
+			}
+		}
+		if len(unions) > 0 {
+			g.P("    let")
+			for _, f := range unions {
+				o := f.Oneof
+				ws := "        "
+				gFP("%s%s o =", ws, o.Type.JSONEncoder)
+				gFP("%s    case o of", ws)
+				ws += "        "
+				for _, v := range o.Variants {
+					fd := v.Field.Desc
+					gFP("%sJust (%s data) ->", ws, v.ID)
+					gFP("%s    [ ( \"%s\", %s data ) ]",
+						ws, fd.JSONName(), fieldJSONEncoder(m, fd))
+				}
+				gFP("%sNothing ->", ws)
+				gFP("%s    []", ws)
+			}
+			g.P("    in")
+		}
+		g.P("    JE.object <|")
+		g.P("        [")
+		var written bool
+		for _, f := range r.Fields {
+			if f.Oneof != nil { // Skip
+				continue
+			}
+			prefix := "            "
+			if written {
+				prefix += ","
+			}
+			gFP("%s ( \"%s\", %s v.%s )",
+				prefix, f.Desc.JSONName(), fieldJSONEncoder(m, f.Desc), f.Label)
+			written = true
+		}
+		g.P("        ]")
+		for _, f := range oneofs {
+			if f.Oneof.IsSynthetic {
+				gFP("        ++ %s.encodeOptional \"%s\" %s v.%s",
+					importElmerJSON, f.Desc.JSONName(), fieldJSONEncoder(m, f.Desc), f.Label)
+			} else {
+				gFP("        ++ %s v.%s", f.Oneof.Type.JSONEncoder, f.Label)
+			}
+		}
+	}
+
+	// Union encoders
+	for _, u := range m.Unions {
+		t := u.Type
+		gFP("%s : %s -> JE.Value", t.JSONEncoder, t)
+		gFP("%s v =", t.JSONEncoder)
+		gFP("    JE.string (%s v)", &ElmRef{t.Module, "from" + t.ID})
+	}
+
+	return true
+}
+
+// A field's JSON decoder including lists and maps
+func fieldJSONDecoder(m *Module, fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		if toKey, _ := mapKeyConv(fd); toKey != "" { // Association list
+			return fmt.Sprintf("(%s.decodeBoolKeys %s)",
+				importElmerJSON, fieldJSONDecoder(m, fd.MapValue()))
+		}
+		key := fd.MapKey()
+		toKey := "Just"
+		if key.Kind() != protoreflect.StringKind {
+			toKey = "String.toInt"
+		}
+		return fmt.Sprintf("(%s.decodeDict %s %s)",
+			importElmerJSON, toKey, fieldJSONDecoder(m, fd.MapValue()))
+	} else if fd.IsList() {
+		return "(JD.list " + fieldJSONKind(m, "JD.", fd) + ")"
+	}
+	return fieldJSONKind(m, "JD.", fd)
+}
+
+// A field's JSON encoder including lists and maps
+func fieldJSONEncoder(m *Module, fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		if toKey, _ := mapKeyConv(fd); toKey != "" { // Association list
+			return fmt.Sprintf("(%s.encodeBoolKeys %s)",
+				importElmerJSON, fieldJSONEncoder(m, fd.MapValue()))
+		}
+		key := fd.MapKey()
+		fromKey := "identity"
+		if key.Kind() != protoreflect.StringKind {
+			fromKey = "String.fromInt"
+		}
+		return fmt.Sprintf("(%s.encodeDict %s %s)",
+			importElmerJSON, fromKey, fieldJSONEncoder(m, fd.MapValue()))
+	} else if fd.IsList() {
+		return "(JE.list " + fieldJSONKind(m, "JE.", fd) + ")"
+	}
+	return fieldJSONKind(m, "JE.", fd)
+}
+
+// Just the Kind. Does not take into account special features like lists.
+func fieldJSONKind(m *Module, lib string, fd protoreflect.FieldDescriptor) string {
+	decoding := lib == "JD."
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return lib + "bool"
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Fixed32Kind:
+		if decoding {
+			return importElmerJSON + ".decodeInt"
+		}
+		return lib + "int"
+
+	// Unsupported by Elm / JS
+	//case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+	//	protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		if decoding {
+			return importElmerJSON + ".decodeFloat"
+		}
+		return importElmerJSON + ".encodeFloat"
+
+	case protoreflect.StringKind:
+		return lib + "string"
+
+	case protoreflect.BytesKind:
+		if decoding {
+			return importElmerJSON + ".decodeBytes"
+		}
+		return importElmerJSON + ".encodeBytes"
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		return m.fieldJSONElmType(decoding, fd, ed.ParentFile(), ed)
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		return m.fieldJSONElmType(decoding, fd, md.ParentFile(), md)
+	}
+
+	m.unsupportedKind(fd)
+	return "Debug.todo \"unsupported\""
+}
+
+func (m *Module) fieldJSONElmType(decoding bool, fd protoreflect.FieldDescriptor, p packager, d fullNamer) string {
+	t := m.NewElmType(p, d)
+	ref := t.JSONEncoder
+	if decoding {
+		ref = t.JSONDecoder
+	}
+	wkt := strings.TrimPrefix(strings.TrimPrefix(ref.ID, "decode"), "encode")
+	if ref.Module == importElmerJSON && !jsonWellKnown[wkt] {
+		m.errorf(fd, "well-known type %s has no JSON codec", d.FullName())
+	}
+	return ref.String()
+}
+
+// Returns the first well-known type reachable from a message that has no JSON codec. Empty if there isn't one
+func jsonUnsupported(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) protoreflect.FullName {
+	if seen[md.FullName()] { // Recursion is checked by the first visit
+		return ""
+	}
+	seen[md.FullName()] = true
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if name := jsonFieldUnsupported(fields.Get(i), seen); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Like jsonUnsupported but for a single field. Maps are checked by their value
+func jsonFieldUnsupported(fd protoreflect.FieldDescriptor, seen map[protoreflect.FullName]bool) protoreflect.FullName {
+	if fd.IsMap() {
+		fd = fd.MapValue()
+	}
+	var name protoreflect.FullName
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		name = fd.Enum().FullName()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		name = fd.Message().FullName()
+	default:
+		return ""
+	}
+	if strings.HasPrefix(string(name), "google.protobuf.") {
+		if !jsonWellKnown[strings.TrimPrefix(string(name), "google.protobuf.")] {
+			return name
+		}
+		return ""
+	}
+	if md := fd.Message(); md != nil {
+		return jsonUnsupported(md, seen)
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Generates elm/random generators for every record, union and oneof. Built from the same fields as fuzzers but without depending on elm-explorations/test, so they can be used in application code e.g., for demo or seed data.
+func GenerateRandom(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	g.P("module ", m.Name, " exposing (..)")
+	gFP("{-| Protobuf library for generating random structures found in package `" + m.ProtoPackage + "`. Sizes and strings are set by a `Protobuf.ElmerRandom.Config`. This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	g.P("import Random exposing (Generator)")
+	printImports(g, m, "Random")
+
+	// Union generators
+	for _, u := range m.Unions {
+		t := u.Type
+		gFP("%s : %s.Config -> Generator %s", t.Random.ID, importElmerRandom, t)
+		gFP("%s _ =", t.Random.ID)
+		gFP("    Random.uniform %s", u.Default().ID)
+		printElmList(g, "        ", len(u.Variants)-1, func(i int) string {
+			return u.Variants[i+1].ID.String()
+		})
+	}
+
+	// Oneof generators
+	for _, o := range m.Oneofs {
+		if o.IsSynthetic { // Optional fields use Protobuf.ElmerRandom.maybe instead
+			continue
+		}
+		t := o.Type
+		gFP("%s : %s.Config -> Generator %s", t.Random.ID, importElmerRandom, t)
+		gFP("%s config =", t.Random.ID)
+		variant := func(v *OneofVariant) string {
+			return fmt.Sprintf("(Random.map %s %s)", v.ID, fieldRandom(m, v.Field.Desc))
+		}
+		gFP("    %s.oneOf", importElmerRandom)
+		gFP("        %s", variant(o.Variants[0]))
+		printElmList(g, "        ", len(o.Variants)-1, func(i int) string {
+			return variant(o.Variants[i+1])
+		})
+	}
+
+	// Record generators
+	for _, r := range m.Records {
+		t := r.Type
+		gFP("%s : %s.Config -> Generator %s", t.Random.ID, importElmerRandom, t)
+		if len(r.Fields) == 0 {
+			gFP("%s _ =", t.Random.ID)
+			gFP("    Random.constant %s", t)
+			continue
+		}
+		gFP("%s config =", t.Random.ID)
+		gFP("    Random.constant %s", t)
+		for _, f := range r.Fields {
+			var gen string
+			switch {
+			case f.Oneof == nil:
+				gen = fieldRandom(m, f.Desc)
+			case f.Oneof.IsSynthetic:
+				gen = fmt.Sprintf("(%s.maybe %s)", importElmerRandom, fieldRandomKind(m, f.Desc))
+			default:
+				gen = fmt.Sprintf("(%s.maybe (%s config))", importElmerRandom, f.Oneof.Type.Random)
+			}
+			gFP("        |> %s.andMap %s", importElmerRandom, gen)
+		}
+	}
+	return true
+}
+
+// Prints an Elm list of n items, one per line
+func printElmList(g *protogen.GeneratedFile, indent string, n int, item func(int) string) {
+	if n == 0 {
+		g.P(indent, "[]")
+		return
+	}
+	for i := 0; i < n; i++ {
+		prefix := ","
+		if i == 0 {
+			prefix = "["
+		}
+		g.P(indent, prefix, " ", item(i))
+	}
+	g.P(indent, "]")
+}
+
+// A field's generator including lists and maps
+func fieldRandom(m *Module, fd protoreflect.FieldDescriptor) string {
+	if fd.IsMap() {
+		key := fieldRandomKind(m, fd.MapKey())
+		val := fieldRandomKind(m, fd.MapValue())
+		if toKey, fromKey := mapKeyConv(fd); toKey != "" { // Sorted association list
+			return fmt.Sprintf("(%s.assoc config %s %s %s %s)", importElmerRandom, toKey, fromKey, key, val)
+		}
+		return fmt.Sprintf("(%s.dict config %s %s)", importElmerRandom, key, val)
+	} else if fd.IsList() {
+		return fmt.Sprintf("(%s.list config %s)", importElmerRandom, fieldRandomKind(m, fd))
+	}
+	return fieldRandomKind(m, fd)
+}
+
+func fieldRandomKind(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "(Random.uniform False [ True ])"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return importElmerRandom + ".int32"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return importElmerRandom + ".uint32"
+
+	// Unsupported by Elm / JS
+	//case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+	//	protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+
+	case protoreflect.FloatKind:
+		return importElmerRandom + ".float32"
+	case protoreflect.DoubleKind:
+		return importElmerRandom + ".float"
+
+	case protoreflect.StringKind:
+		return "(" + importElmerRandom + ".string config)"
+	case protoreflect.BytesKind:
+		return "(" + importElmerRandom + ".bytes config)"
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		return "(" + m.NewElmType(ed.ParentFile(), ed).Random.String() + " config)"
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		return "(" + m.NewElmType(md.ParentFile(), md).Random.String() + " config)"
+	}
+
+	m.unsupportedKind(fd)
+	return "Random.constant (Debug.todo \"unsupported\")"
+}
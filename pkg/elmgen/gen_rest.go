@@ -0,0 +1,250 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const importElmerRest = "Protobuf.ElmerRest"
+
+// Generates a REST client for RPC methods with a `google.api.http` option. Messages are sent as JSON using the codecs from GenerateJSON. Other methods are skipped.
+func GenerateREST(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	gFP("module %s exposing (..)", m.Name)
+	gFP("{-| Protobuf library for executing RPC methods defined in package `" + m.ProtoPackage + "` against a REST API described by `google.api.http` options. This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	g.P("import Http")
+	g.P("import Json.Decode as JD")
+	g.P("import Url.Builder")
+	gFP("import %s", importElmerRest)
+	if !contains(m.Imports, importElmer) { // Needed for bytes in URLs
+		gFP("import %s", importElmer)
+	}
+	printImports(g, m, "Json")
+
+	var valid bool
+	for _, s := range m.Services {
+		s.Comments.printDashDash(g)
+		for _, rpc := range s.Methods {
+			if rpc.IsStreaming() {
+				gFP("-- Skipped %s.%s: streaming methods are generated by protoc-gen-elmer-streams", rpc.Service, rpc.Method)
+				continue
+			} else if rpc.HTTP == nil {
+				gFP("-- Skipped %s.%s: no google.api.http option", rpc.Service, rpc.Method)
+				continue
+			}
+			rule := rpc.HTTP
+			in, out := rpc.Desc.Input(), rpc.Desc.Output()
+			segments, err := parsePathTemplate(rule.Path, in)
+			if err != nil {
+				m.errorf(rpc.Desc, "%s", err)
+				continue
+			}
+			var body, responseBody protoreflect.FieldDescriptor
+			if rule.Body != "" && rule.Body != "*" {
+				if body = restBodyField(m, rpc, in, rule.Body); body == nil {
+					continue
+				}
+			}
+			if rule.ResponseBody != "" && rule.ResponseBody != "*" {
+				if responseBody = restBodyField(m, rpc, out, rule.ResponseBody); responseBody == nil {
+					continue
+				}
+			}
+			// JSON bodies must have a codec
+			var unsupported protoreflect.FullName
+			if rule.Body == "*" {
+				unsupported = jsonUnsupported(in, make(map[protoreflect.FullName]bool))
+			} else if body != nil {
+				unsupported = jsonFieldUnsupported(body, make(map[protoreflect.FullName]bool))
+			}
+			if unsupported == "" && !rpc.OutEmpty {
+				if responseBody == nil {
+					unsupported = jsonUnsupported(out, make(map[protoreflect.FullName]bool))
+				} else {
+					unsupported = jsonFieldUnsupported(responseBody, make(map[protoreflect.FullName]bool))
+				}
+			}
+			if unsupported != "" {
+				m.errorf(rpc.Desc, "well-known type %s has no JSON codec", unsupported)
+				continue
+			}
+			valid = true
+
+			id := rpc.IDWithPrefix("rest")
+			rpc.Comments.printBlock(g)
+			inSig, inParam := rpc.InParam()
+			gFP("%s : (Result Http.Error %s -> msg)\n -> String%s -> Cmd msg",
+				id, rpc.OutType(), inSig)
+			gFP("%s msg api%s =", id, inParam)
+			gFP("    Http.riskyRequest")
+			gFP(`        { method = "%s"`, rule.Method)
+			gFP(`        , headers = []`)
+			gFP(`        , url =`)
+			gFP(`            api`)
+			// Path
+			bound := make(map[string]bool)
+			for _, seg := range segments {
+				if seg.Fields == nil {
+					gFP(`                ++ "%s"`, seg.Literal)
+					continue
+				}
+				var names, labels []string
+				for _, fd := range seg.Fields {
+					names = append(names, string(fd.Name()))
+					labels = append(labels, protoIdentToElmValue(string(fd.Name())))
+				}
+				bound[strings.Join(names, ".")] = true
+				encode := "segment"
+				if seg.Multi {
+					encode = "segments"
+				}
+				value := "data." + strings.Join(labels, ".")
+				if conv := restToString(m, seg.Fields[len(seg.Fields)-1]); conv != "identity" {
+					value = "(" + conv + " " + value + ")"
+				}
+				gFP("                ++ %s.%s %s", importElmerRest, encode, value)
+			}
+			// Query: fields not in the path or body
+			var query []string
+			switch rule.Body {
+			case "*":
+			case "":
+				query = restQuery(m, in, "", "data", bound, make(map[protoreflect.FullName]bool))
+			default:
+				bound[rule.Body] = true
+				query = restQuery(m, in, "", "data", bound, make(map[protoreflect.FullName]bool))
+			}
+			if len(query) > 0 {
+				gFP("                ++ Url.Builder.toQuery")
+				gFP("                    (List.concat")
+				for i, q := range query {
+					prefix := ","
+					if i == 0 {
+						prefix = "["
+					}
+					gFP("                        %s %s", prefix, q)
+				}
+				gFP("                        ])")
+			}
+			// Body
+			switch rule.Body {
+			case "":
+				gFP("        , body = Http.emptyBody")
+			case "*":
+				gFP("        , body = Http.jsonBody (%s %s)", rpc.In.JSONEncoder, rpc.InData())
+			default:
+				gFP("        , body = Http.jsonBody (%s data.%s)", fieldJSONEncoder(m, body),
+					protoIdentToElmValue(string(body.Name())))
+			}
+			// Response
+			if rpc.OutEmpty { // Servers may not send a body
+				gFP("        , expect = Http.expectWhatever msg")
+			} else if responseBody == nil {
+				gFP("        , expect = Http.expectJson msg %s", rpc.Out.JSONDecoder)
+			} else {
+				gFP("        , expect =")
+				gFP("            Http.expectJson msg")
+				gFP("                (JD.map (\\v -> let zero = %s in { zero | %s = v }) %s)",
+					rpc.Out.Zero, protoIdentToElmValue(string(responseBody.Name())), fieldJSONDecoder(m, responseBody))
+			}
+			gFP(`        , timeout = Nothing`)
+			gFP(`        , tracker = Nothing`)
+			gFP("        }")
+			rpc.Comments.printBlockTrailing(g)
+		}
+		g.P(s.Comments.Trailing)
+	}
+
+	return valid
+}
+
+// Finds a top-level field named by a `body` or `response_body` rule. Returns nil (and records an error) if there isn't one
+func restBodyField(m *Module, rpc *RPC, md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fd := md.Fields().ByName(protoreflect.Name(name))
+	if fd == nil || fd.ContainingOneof() != nil {
+		m.errorf(rpc.Desc, "body field %q must be a regular field of %s", name, md.FullName())
+		return nil
+	}
+	return fd
+}
+
+// Lists query parameter expressions for a message's fields. Nested messages use dotted names e.g., "book.id". Fields in the path (or body) are skipped as are those that can't be represented: maps, oneofs, repeated messages, well-known types and recursive messages.
+func restQuery(m *Module, md protoreflect.MessageDescriptor, namePrefix, elmPrefix string, bound map[string]bool, seen map[protoreflect.FullName]bool) []string {
+	seen[md.FullName()] = true
+	defer delete(seen, md.FullName())
+	var query []string
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := namePrefix + string(fd.Name())
+		access := elmPrefix + "." + protoIdentToElmValue(string(fd.Name()))
+		oneof := fd.ContainingOneof()
+		if bound[name] || fd.IsMap() || (oneof != nil && !oneof.IsSynthetic()) {
+			continue
+		}
+
+		if msg := fd.Message(); msg != nil {
+			// Only singular, non-optional messages that we generated
+			if fd.IsList() || oneof != nil || seen[msg.FullName()] ||
+				strings.HasPrefix(string(msg.FullName()), "google.protobuf.") {
+				continue
+			}
+			query = append(query, restQuery(m, msg, name+".", access, bound, seen)...)
+			continue
+		}
+
+		helper := "query"
+		if fd.IsList() {
+			helper = "queryList"
+		} else if oneof != nil { // Optional
+			helper = "queryMaybe"
+		}
+		query = append(query, fmt.Sprintf(`%s.%s "%s" %s %s`,
+			importElmerRest, helper, name, restToString(m, fd), access))
+	}
+	return query
+}
+
+// Returns an Elm function converting a scalar field to a string for use in a URL
+func restToString(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return importElmerRest + ".fromBool"
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Fixed32Kind:
+		return "String.fromInt"
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "String.fromFloat"
+
+	case protoreflect.StringKind:
+		return "identity"
+
+	case protoreflect.BytesKind:
+		return importElmer + ".toBase64"
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		t := m.NewElmType(ed.ParentFile(), ed)
+		return (&ElmRef{t.Module, "from" + t.ID}).String()
+	}
+
+	m.unsupportedKind(fd)
+	return "Debug.todo \"unsupported\""
+}
@@ -0,0 +1,87 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const importElmerStreams = "Protobuf.ElmerStreams"
+
+// Generates a port module for streaming RPC methods. Each method gets a pair of ports that are expected to be connected to a WebSocket by `js/elmer-streams.js`. Unary methods are skipped, see GenerateTwirp instead.
+func GenerateStreams(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	gFP("port module %s exposing (..)", m.Name)
+	gFP("{-| Protobuf library for streaming RPC methods defined in package `" + m.ProtoPackage + "` over WebSockets. Each method has a pair of ports which must be connected using `connectElmerStreams` from `js/elmer-streams.js`. Streams are identified by a handle of your choosing so that more than one may be open at a time. This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	gFP("import %s", importElmerStreams)
+	printImports(g, m)
+
+	var valid bool
+	for _, s := range m.Services {
+		s.Comments.printDashDash(g)
+		for _, rpc := range s.Methods {
+			if !rpc.IsStreaming() {
+				continue
+			}
+			valid = true
+			portOut, portIn := streamPorts(m, rpc)
+			gFP("port %s : %s.Frame -> Cmd msg", portOut, importElmerStreams)
+			gFP("port %s : (%s.Frame -> msg) -> Sub msg", portIn, importElmerStreams)
+
+			openID := rpc.IDWithPrefix("open")
+			rpc.Comments.printBlock(g)
+			gFP("%s : String -> String -> Cmd msg", openID)
+			gFP("%s api handle =", openID)
+			gFP(`    %s.open (api ++ "/%s/%s") handle`, importElmerStreams, rpc.Service, rpc.Method)
+			gFP("        |> %s", portOut)
+			rpc.Comments.printBlockTrailing(g)
+
+			sendID := rpc.IDWithPrefix("send")
+			inSig, inParam := rpc.InParam()
+			gFP("%s : String%s -> Cmd msg", sendID, inSig)
+			gFP("%s handle%s =", sendID, inParam)
+			gFP("    %s.send %s handle %s", importElmerStreams, rpc.In.Encoder, rpc.InData())
+			gFP("        |> %s", portOut)
+
+			closeID := rpc.IDWithPrefix("close")
+			gFP("%s : String -> Cmd msg", closeID)
+			gFP("%s handle =", closeID)
+			gFP("    %s.close handle", importElmerStreams)
+			gFP("        |> %s", portOut)
+
+			listenID := rpc.IDWithPrefix("listen")
+			gFP("%s : (%s.Event %s -> msg) -> Sub msg", listenID, importElmerStreams, rpc.OutType())
+			gFP("%s msg =", listenID)
+			decoder := rpc.Out.Decoder.String()
+			if rpc.OutEmpty {
+				decoder = "(PD.map (always ()) " + decoder + ")"
+			}
+			gFP("    %s (%s.toEvent %s >> msg)", portIn, importElmerStreams, decoder)
+		}
+		g.P(s.Comments.Trailing)
+	}
+
+	return valid
+}
+
+// Returns the out and in port names of a streaming RPC. Port names are global to an Elm program so they're prefixed with the module name.
+func streamPorts(m *Module, rpc *RPC) (portOut, portIn string) {
+	runes := []rune(strings.ReplaceAll(m.Name, ".", ""))
+	runes[0] = unicode.ToLower(runes[0])
+	base := string(runes) + "_" + rpc.IDWithPrefix("")
+	return base + "Out", base + "In"
+}
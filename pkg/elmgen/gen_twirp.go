@@ -11,9 +11,28 @@ import (
 	"fmt"
 
 	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+const (
+	importElmerHTTP   = "Protobuf.ElmerHttp"
+	importElmerEffect = "Protobuf.ElmerEffect"
+	importElmerPages  = "Protobuf.ElmerPages"
+)
+
+// Options for generating a Twirp client
+type TwirpOptions struct {
+	Metadata bool // Results hold the HTTP response's metadata. See Protobuf.ElmerHttp
+	Effects  bool // Adds an Effect type describing RPC calls as data. See Protobuf.ElmerEffect
+}
+
+// Generates a Twirp client with default options
 func GenerateTwirp(m *Module, g *protogen.GeneratedFile) bool {
+	return TwirpOptions{}.Generate(m, g)
+}
+
+// Generates a Twirp client for all non-streaming RPC methods
+func (opts TwirpOptions) Generate(m *Module, g *protogen.GeneratedFile) bool {
 	gFP := func(formatter string, args ...interface{}) {
 		g.P(fmt.Sprintf(formatter, args...))
 	}
@@ -23,31 +42,282 @@ func GenerateTwirp(m *Module, g *protogen.GeneratedFile) bool {
 	printDoNotEdit(g)
 
 	gFP("import Http")
-	printImports(g, m, true)
+	gFP("import %s", importElmerHTTP)
+	if opts.Effects {
+		gFP("import %s", importElmerEffect)
+	}
+	g.P("import Task exposing (Task)")
+	if m.hasPagination() {
+		gFP("import %s", importElmerPages)
+	}
+	printImports(g, m)
 
+	var valid bool
+	var effects []*RPC
 	for _, s := range m.Services {
 		s.Comments.printDashDash(g)
 		for _, rpc := range s.Methods {
+			// Twirp doesn't support streaming. See GenerateStreams instead
+			if rpc.IsStreaming() {
+				gFP("-- Skipped %s.%s: streaming methods are generated by protoc-gen-elmer-streams", rpc.Service, rpc.Method)
+				continue
+			}
+			valid = true
+			effects = append(effects, rpc)
 			rpc.Comments.printBlock(g)
-			gFP("%s : (Result Http.Error %s -> msg)\n -> String -> %s -> Cmd msg",
-				rpc.ID.ID, rpc.Out, rpc.In)
-			gFP("%s msg api data =", rpc.ID.ID)
-			gFP("    Http.riskyRequest")
-			gFP(`        { method = "POST"`)
-			gFP(`        , headers = []`)
-			gFP(`        , url = api ++ "/%s/%s"`, rpc.Service, rpc.Method)
-			gFP("        , body =")
-			gFP("            %s data", rpc.In.Encoder)
-			gFP("                |> PE.encode")
-			gFP(`                |> Http.bytesBody "application/protobuf"`)
-			gFP("        , expect = PD.expectBytes msg %s", rpc.Out.Decoder)
-			gFP(`        , timeout = Nothing`)
-			gFP(`        , tracker = Nothing`)
-			gFP("        }")
+			inSig, inParam := rpc.InParam()
+			gFP("%s : (Result %s -> msg)\n -> String%s -> Cmd msg",
+				rpc.ID.ID, opts.result(rpc), inSig)
+			gFP("%s msg api%s =", rpc.ID.ID, inParam)
+			opts.printRequest(g, rpc, "    ", "[]", "Nothing")
 			rpc.Comments.printBlockTrailing(g)
+			if rpc.Pages != nil {
+				printPagination(m, g, rpc)
+			}
 		}
+		opts.printClient(g, s)
 		g.P(s.Comments.Trailing)
 	}
 
-	return len(m.Services) > 0
+	if opts.Effects && valid {
+		opts.printEffects(g, effects)
+	}
+	return valid
+}
+
+// Prints an Http.riskyRequest for an RPC. Expects `msg`, `api` and, unless Empty, `data` in scope
+func (opts TwirpOptions) printRequest(g *protogen.GeneratedFile, rpc *RPC, indent, headers, timeout string) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(indent + fmt.Sprintf(formatter, args...))
+	}
+	expect := "PD.expectBytes"
+	if opts.Metadata {
+		expect = importElmerHTTP + ".expectResponse"
+	}
+	gFP("Http.riskyRequest")
+	gFP(`    { method = "POST"`)
+	gFP(`    , headers = %s`, headers)
+	gFP(`    , url = api ++ "/%s/%s"`, rpc.Service, rpc.Method)
+	gFP("    , body =")
+	gFP("        %s %s", rpc.In.Encoder, rpc.InData())
+	gFP("            |> PE.encode")
+	gFP(`            |> Http.bytesBody "application/protobuf"`)
+	gFP("    , expect = %s msg %s", expect, outDecoder(rpc))
+	gFP(`    , timeout = %s`, timeout)
+	gFP(`    , tracker = Nothing`)
+	gFP("    }")
+}
+
+// Prints a record type holding a service's (non-streaming) RPCs along with a constructor and a stub for injecting the service as a single value
+func (opts TwirpOptions) printClient(g *protogen.GeneratedFile, s *Service) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	var rpcs []*RPC
+	for _, rpc := range s.Methods {
+		if !rpc.IsStreaming() {
+			rpcs = append(rpcs, rpc)
+		}
+	}
+	if len(rpcs) == 0 {
+		return
+	}
+	field := func(rpc *RPC) string {
+		return protoIdentToElmValue(string(rpc.Method))
+	}
+	printRecord := func(value func(*RPC) string) {
+		for i, rpc := range rpcs {
+			sep := ","
+			if i == 0 {
+				sep = "{"
+			}
+			gFP("    %s %s%s", sep, field(rpc), value(rpc))
+		}
+		g.P("    }")
+	}
+	client := s.Label + "Client"
+	stub := s.Label + "Stub"
+	value := protoIdentToElmValue(s.Label)
+
+	gFP("{-| RPC methods of `%s` for passing around as a single value. See `%sClient` and `stub%s`. -}",
+		rpcs[0].Service, value, s.Label)
+	gFP("type alias %s msg =", client)
+	printRecord(func(rpc *RPC) string {
+		inSig, _ := rpc.InParam()
+		return fmt.Sprintf(" : (Result %s -> msg)%s -> Cmd msg", opts.result(rpc), inSig)
+	})
+
+	gFP("{-| Creates a `%s` client for a Twirp server at the given URL prefix. -}", rpcs[0].Service)
+	gFP("%sClient : String -> %s.Config -> %s msg", value, importElmerHTTP, client)
+	gFP("%sClient api config =", value)
+	for i, rpc := range rpcs {
+		sep := ","
+		if i == 0 {
+			sep = "{"
+		}
+		_, inParam := rpc.InParam()
+		gFP("    %s %s =", sep, field(rpc))
+		gFP("        \\msg%s ->", inParam)
+		opts.printRequest(g, rpc, "            ", "config.headers", "config.timeout")
+	}
+	g.P("    }")
+
+	gFP("{-| Canned responses for `stub%s`. -}", s.Label)
+	gFP("type alias %s =", stub)
+	printRecord(func(rpc *RPC) string {
+		if rpc.InEmpty {
+			return " : Result " + opts.result(rpc)
+		}
+		return fmt.Sprintf(" : %s -> Result %s", rpc.In, opts.result(rpc))
+	})
+
+	gFP("{-| Responds to every call with the zero value of its result. -}")
+	gFP("default%s : %s", stub, stub)
+	gFP("default%s =", stub)
+	printRecord(func(rpc *RPC) string {
+		zero := rpc.Out.Zero.String()
+		if rpc.OutEmpty {
+			zero = "()"
+		}
+		if opts.Metadata {
+			zero = fmt.Sprintf("(%s.Response %s.stubMetadata %s)", importElmerHTTP, importElmerHTTP, zero)
+		}
+		if rpc.InEmpty {
+			return " = Ok " + zero
+		}
+		return " = \\_ -> Ok " + zero
+	})
+
+	gFP("{-| A `%s` client that doesn't make requests. Calls are answered with the given responses instead. -}", rpcs[0].Service)
+	gFP("stub%s : %s -> %s msg", s.Label, stub, client)
+	gFP("stub%s responses =", s.Label)
+	printRecord(func(rpc *RPC) string {
+		if rpc.InEmpty {
+			return fmt.Sprintf(" = \\msg -> Task.perform msg (Task.succeed responses.%s)", field(rpc))
+		}
+		return fmt.Sprintf(" = \\msg data -> Task.perform msg (Task.succeed (responses.%s data))", field(rpc))
+	})
+}
+
+// The Result type given to an RPC's msg
+func (opts TwirpOptions) result(rpc *RPC) string {
+	if opts.Metadata {
+		return fmt.Sprintf("%s.Error (%s.Response %s)",
+			importElmerHTTP, importElmerHTTP, rpc.OutType())
+	}
+	return "Http.Error " + rpc.OutType()
+}
+
+// Decodes an RPC's response. Empty is mapped to the unit type
+func outDecoder(rpc *RPC) string {
+	if rpc.OutEmpty {
+		return fmt.Sprintf("(PD.map (always ()) %s)", rpc.Out.Decoder)
+	}
+	return rpc.Out.Decoder.String()
+}
+
+// Returns true if any non-streaming RPC is paginated
+func (m *Module) hasPagination() bool {
+	for _, s := range m.Services {
+		for _, rpc := range s.Methods {
+			if rpc.Pages != nil && !rpc.IsStreaming() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Prints AIP-158 helpers for a paginated RPC: a task fetching a single page, a function to move to the next page and a task fetching all pages
+func printPagination(m *Module, g *protogen.GeneratedFile, rpc *RPC) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+	pages := rpc.Pages
+	label := func(fd protoreflect.FieldDescriptor) string {
+		return protoIdentToElmValue(string(fd.Name()))
+	}
+	task, next, all := rpc.IDWithPrefix("task"), rpc.IDWithPrefix("nextPage"), rpc.IDWithPrefix("fetchAll")
+
+	gFP("{-| Fetches a single page of `%s.%s` as a task. -}", rpc.Service, rpc.Method)
+	gFP("%s : String -> %s.Config -> %s -> Task Http.Error %s", task, importElmerHTTP, rpc.In, rpc.Out)
+	gFP("%s api config data =", task)
+	gFP("    Http.riskyTask")
+	gFP(`        { method = "POST"`)
+	gFP(`        , headers = config.headers`)
+	gFP(`        , url = api ++ "/%s/%s"`, rpc.Service, rpc.Method)
+	gFP("        , body =")
+	gFP("            %s data", rpc.In.Encoder)
+	gFP("                |> PE.encode")
+	gFP(`                |> Http.bytesBody "application/protobuf"`)
+	gFP("        , resolver = %s.resolver %s", importElmerPages, rpc.Out.Decoder)
+	gFP(`        , timeout = config.timeout`)
+	gFP("        }")
+
+	gFP("{-| Returns the request for the page after a response. Nothing if it was the last page. -}")
+	gFP("%s : %s -> %s -> Maybe %s", next, rpc.In, rpc.Out, rpc.In)
+	gFP("%s data resp =", next)
+	gFP(`    if resp.%s == "" then`, label(pages.NextPageToken))
+	gFP("        Nothing")
+	gFP("    else")
+	gFP("        Just { data | %s = resp.%s }", label(pages.PageToken), label(pages.NextPageToken))
+
+	gFP("{-| Fetches pages of `%s.%s` until the last page or the maximum number of pages, concatenating `%s`. Set `%s` to choose the size of each page. -}",
+		rpc.Service, rpc.Method, pages.Results.Name(), pages.PageSize.Name())
+	gFP("%s : Int -> String -> %s.Config -> %s -> Task Http.Error %s",
+		all, importElmerHTTP, rpc.In, fieldTypeDesc(m, pages.Results))
+	gFP("%s maxPages api config =", all)
+	gFP("    %s.fetchAll maxPages (%s api config) %s .%s", importElmerPages, task, next, label(pages.Results))
+}
+
+// Prints an Effect type with a variant per RPC. Effects can be inspected (e.g., in tests) before being performed
+func (opts TwirpOptions) printEffects(g *protogen.GeneratedFile, rpcs []*RPC) {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	g.P("{-| Describes an RPC call as data. Use `perform` to turn it into a `Cmd`. -}")
+	g.P("type Effect msg")
+	for i, rpc := range rpcs {
+		prefix := "="
+		if i > 0 {
+			prefix = "|"
+		}
+		inSig := ""
+		if !rpc.InEmpty {
+			inSig = " " + rpc.In.String()
+		}
+		gFP("    %s %s (Result %s -> msg)%s", prefix, rpc.IDWithPrefix(""), opts.result(rpc), inSig)
+	}
+
+	g.P("mapEffect : (a -> b) -> Effect a -> Effect b")
+	g.P("mapEffect f effect =")
+	g.P("    case effect of")
+	for _, rpc := range rpcs {
+		_, inParam := rpc.InParam()
+		gFP("        %s msg%s ->", rpc.IDWithPrefix(""), inParam)
+		gFP("            %s (msg >> f)%s", rpc.IDWithPrefix(""), inParam)
+	}
+
+	gFP("describe : Effect msg -> %s.Rpc msg", importElmerEffect)
+	g.P("describe effect =")
+	g.P("    case effect of")
+	for _, rpc := range rpcs {
+		_, inParam := rpc.InParam()
+		respond := fmt.Sprintf("%s.respond msg %s", importElmerEffect, outDecoder(rpc))
+		if opts.Metadata {
+			respond = fmt.Sprintf("msg << %s.resolve %s", importElmerHTTP, outDecoder(rpc))
+		}
+		gFP("        %s msg%s ->", rpc.IDWithPrefix(""), inParam)
+		gFP(`            { service = "%s"`, rpc.Service)
+		gFP(`            , method = "%s"`, rpc.Method)
+		gFP("            , request = PE.encode (%s %s)", rpc.In.Encoder, rpc.InData())
+		gFP("            , respond = %s", respond)
+		g.P("            }")
+	}
+
+	g.P("{-| Performs an RPC call against a Twirp server at the given URL prefix. -}")
+	g.P("perform : String -> Effect msg -> Cmd msg")
+	g.P("perform api =")
+	gFP("    describe >> %s.toCmd api", importElmerEffect)
 }
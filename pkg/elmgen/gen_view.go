@@ -0,0 +1,135 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Generates a Html view for every record, union and oneof. Renders a collapsible tree labelled with Protobuf names and numbers, intended for admin and debug panels.
+func GenerateView(m *Module, g *protogen.GeneratedFile) bool {
+	gFP := func(formatter string, args ...interface{}) {
+		g.P(fmt.Sprintf(formatter, args...))
+	}
+
+	g.P("module ", m.Name, " exposing (..)")
+	gFP("{-| Protobuf library for viewing structures found in package `" + m.ProtoPackage + "` as Html. Each view is a collapsible tree labelled with Protobuf field names and numbers. This file was generated automatically by `protoc-gen-elmer`. See the base file for more information. Do not edit. -}")
+	printDoNotEdit(g)
+
+	g.P("import Html exposing (Html)")
+	printImports(g, m, "View")
+
+	// Union views
+	for _, u := range m.Unions {
+		t := u.Type
+		gFP("%s : %s -> Html msg", t.View.ID, t)
+		gFP("%s v =", t.View.ID)
+		gFP("    %s.enum (%s v) (%s v)", importElmerView,
+			&ElmRef{t.Module, "from" + t.ID}, &ElmRef{t.Module, "fromInt" + t.ID})
+	}
+
+	// Oneof views
+	for _, o := range m.Oneofs {
+		if o.IsSynthetic { // Optional fields use Protobuf.ElmerView.maybe instead
+			continue
+		}
+		t := o.Type
+		gFP("%s : %s -> Html msg", t.View.ID, t)
+		gFP("%s v =", t.View.ID)
+		g.P("    case v of")
+		for _, v := range o.Variants {
+			fd := v.Field.Desc
+			gFP("        %s x ->", v.ID)
+			gFP("            %s", fieldView(fd, fieldViewValue(m, fd, "x")))
+		}
+	}
+
+	// Record views
+	for _, r := range m.Records {
+		t := r.Type
+		gFP("%s : %s -> Html msg", t.View.ID, t)
+		if len(r.Fields) == 0 {
+			gFP("%s _ =", t.View.ID)
+			gFP("    %s.record \"%s\" []", importElmerView, r.Desc.FullName())
+			continue
+		}
+		gFP("%s v =", t.View.ID)
+		gFP("    %s.record \"%s\"", importElmerView, r.Desc.FullName())
+		printElmList(g, "        ", len(r.Fields), func(i int) string {
+			f := r.Fields[i]
+			value := "v." + f.Label
+			switch {
+			case f.Oneof == nil:
+				return fieldView(f.Desc, fieldViewValue(m, f.Desc, value))
+			case f.Oneof.IsSynthetic:
+				return fieldView(f.Desc, fmt.Sprintf("(%s.maybe %s %s)",
+					importElmerView, fieldViewKind(m, f.Desc), value))
+			default:
+				name := f.Oneof.Variants[0].Field.Desc.ContainingOneof().Name()
+				return fmt.Sprintf("%s.oneof \"%s\" %s %s", importElmerView, name, f.Oneof.Type.View, value)
+			}
+		})
+	}
+	return true
+}
+
+// Labels a field's view with its Protobuf name and number
+func fieldView(fd protoreflect.FieldDescriptor, view string) string {
+	return fmt.Sprintf("%s.field \"%s\" %d %s", importElmerView, fd.Name(), fd.Number(), view)
+}
+
+// Views a field's value including lists and maps
+func fieldViewValue(m *Module, fd protoreflect.FieldDescriptor, value string) string {
+	if fd.IsMap() {
+		key := fieldViewKind(m, fd.MapKey())
+		val := fieldViewKind(m, fd.MapValue())
+		if toKey, _ := mapKeyConv(fd); toKey == "" { // Dict rather than an association list
+			value = "(Dict.toList " + value + ")"
+		}
+		return fmt.Sprintf("(%s.dict %s %s %s)", importElmerView, key, val, value)
+	} else if fd.IsList() {
+		return fmt.Sprintf("(%s.list %s %s)", importElmerView, fieldViewKind(m, fd), value)
+	}
+	return fmt.Sprintf("(%s %s)", fieldViewKind(m, fd), value)
+}
+
+func fieldViewKind(m *Module, fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return importElmerView + ".bool"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Fixed32Kind:
+		return importElmerView + ".int"
+
+	// Unsupported by Elm / JS
+	//case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+	//	protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return importElmerView + ".float"
+
+	case protoreflect.StringKind:
+		return importElmerView + ".string"
+	case protoreflect.BytesKind:
+		return importElmerView + ".bytes"
+
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		return m.NewElmType(ed.ParentFile(), ed).View.String()
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		return m.NewElmType(md.ParentFile(), md).View.String()
+	}
+
+	m.unsupportedKind(fd)
+	return "(Debug.todo \"unsupported\")"
+}
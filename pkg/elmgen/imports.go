@@ -14,23 +14,38 @@ import (
 )
 
 const (
-	importBytes      = "Bytes"
-	importDict       = "Dict"
-	importGooglePB   = "Google.Protobuf"
-	importElmer      = "Protobuf.Elmer"
-	importElmerTests = "Protobuf.ElmerTests"
+	importBytes         = "Bytes"
+	importDict          = "Dict"
+	importGooglePB      = "Google.Protobuf"
+	importElmer         = "Protobuf.Elmer"
+	importElmerJSON     = "Protobuf.ElmerJson"
+	importElmerTests    = "Protobuf.ElmerTests"
+	importElmerRandom   = "Protobuf.ElmerRandom"
+	importElmerView     = "Protobuf.ElmerView"
+	importElmerExplorer = "Protobuf.ElmerExplorer"
 )
 
 // Adds a new Module import. Must be an Elm Module reference e.g. "Protobuf.Decode"
 func (m *Module) addImport(mod string) {
+	if _, ok := m.importsSeen[mod]; mod != "" && !ok {
+		m.importsSeen[mod] = ""
+	}
+}
+
+// Adds an import of a module derived from a codec module e.g., "Tests" for fuzzers. Since our Elm types always reference them, these are skipped unless asked for (see `printImports`)
+func (m *Module) addDerivedImport(mod, suffix string) {
 	if mod != "" {
-		m.importsSeen[mod] = true
+		m.importsSeen[mod] = suffix
 	}
 }
 
 // Finds extra imports once module has been filled with known data strucutres
 func (m *Module) findImports() {
-	m.addImport(importElmerTests) // Needed by all tests, removed by non-test modules
+	m.addDerivedImport(importElmerTests, "Tests")       // Needed by all tests, removed by non-test modules
+	m.addDerivedImport(importElmerJSON, "Json")         // Needed by all JSON codecs, removed by other modules
+	m.addDerivedImport(importElmerRandom, "Random")     // Needed by all random generators, removed by other modules
+	m.addDerivedImport(importElmerView, "View")         // Needed by all debug viewers, removed by other modules
+	m.addDerivedImport(importElmerExplorer, "Explorer") // Needed by all RPC explorers, removed by other modules
 	// Iterate over fields since they hold non-ref values which can trigger imports
 	for _, r := range m.Records {
 		for _, f := range r.Fields {
@@ -50,6 +65,9 @@ func (m *Module) findImports() {
 func (m *Module) fieldImports(fd protoreflect.FieldDescriptor) {
 	if fd.IsMap() { // Dict
 		m.addImport(importDict)
+		if toKey, _ := mapKeyConv(fd); toKey != "" { // Association list
+			m.addImport(importElmer)
+		}
 		m.fieldImports(fd.MapKey())
 		m.fieldImports(fd.MapValue())
 	} else {
@@ -72,11 +90,20 @@ func (m *Module) fieldImports(fd protoreflect.FieldDescriptor) {
 /* Elm type constructors */
 
 func (m *Module) newElmRef(mod, id string) *ElmRef {
+	return m.newDerivedElmRef(mod, "", id)
+}
+
+// Creates a reference to a module derived from a codec module. See `addDerivedImport`
+func (m *Module) newDerivedElmRef(mod, suffix, id string) *ElmRef {
 	ref := &ElmRef{mod, id}
 	if mod == m.Name { // Local?
 		ref.Module = ""
 	}
-	m.addImport(ref.Module)
+	if suffix == "" {
+		m.addImport(ref.Module)
+	} else {
+		m.addDerivedImport(ref.Module, suffix)
+	}
 	return ref
 }
 
@@ -118,14 +145,24 @@ func (m *Module) NewElmType(p packager, d fullNamer) *ElmType {
 				m.newElmRef(importElmer, "empty"+asType),
 				m.newElmRef(importElmer, "decode"+asType),
 				m.newElmRef(importElmer, "encode"+asType),
-				m.newElmRef(importElmerTests, "fuzz"+asType)}
+				m.newDerivedElmRef(importElmerTests, "Tests", "fuzz"+asType),
+				m.newDerivedElmRef(importElmerJSON, "Json", "decode"+asType),
+				m.newDerivedElmRef(importElmerJSON, "Json", "encode"+asType),
+				m.newDerivedElmRef(importElmerRandom, "Random", "random"+asType),
+				m.newDerivedElmRef(importElmerView, "View", "view"+asType),
+				m.newDerivedElmRef(importElmerExplorer, "Explorer", "form"+asType)}
 		} else if asType == "Timestamp" {
 			return &ElmType{
 				m.newElmRef("Time", "Posix"),
 				m.newElmRef(importElmer, "empty"+asType),
 				m.newElmRef(importElmer, "decode"+asType),
 				m.newElmRef(importElmer, "encode"+asType),
-				m.newElmRef(importElmerTests, "fuzz"+asType)}
+				m.newDerivedElmRef(importElmerTests, "Tests", "fuzz"+asType),
+				m.newDerivedElmRef(importElmerJSON, "Json", "decode"+asType),
+				m.newDerivedElmRef(importElmerJSON, "Json", "encode"+asType),
+				m.newDerivedElmRef(importElmerRandom, "Random", "random"+asType),
+				m.newDerivedElmRef(importElmerView, "View", "view"+asType),
+				m.newDerivedElmRef(importElmerExplorer, "Explorer", "form"+asType)}
 		} else {
 			// Passthru to Google.Protobuf
 			gpType, gpValue := asType, asValue
@@ -143,7 +180,12 @@ func (m *Module) NewElmType(p packager, d fullNamer) *ElmType {
 				m.newElmRef(importElmer, "empty"+asType),
 				m.newElmRef(importGooglePB, gpValue+"Decoder"),
 				m.newElmRef(importGooglePB, "to"+gpType+"Encoder"),
-				m.newElmRef(importElmerTests, "fuzz"+asType)}
+				m.newDerivedElmRef(importElmerTests, "Tests", "fuzz"+asType),
+				m.newDerivedElmRef(importElmerJSON, "Json", "decode"+asType),
+				m.newDerivedElmRef(importElmerJSON, "Json", "encode"+asType),
+				m.newDerivedElmRef(importElmerRandom, "Random", "random"+asType),
+				m.newDerivedElmRef(importElmerView, "View", "view"+asType),
+				m.newDerivedElmRef(importElmerExplorer, "Explorer", "form"+asType)}
 		}
 	}
 	return &ElmType{
@@ -151,7 +193,12 @@ func (m *Module) NewElmType(p packager, d fullNamer) *ElmType {
 		m.newElmRef(mod, "empty"+asType),
 		m.newElmRef(mod, "decode"+asType),
 		m.newElmRef(mod, "encode"+asType),
-		m.newElmRef(mod+"Tests", "fuzz"+asType)}
+		m.newDerivedElmRef(mod+"Tests", "Tests", "fuzz"+asType),
+		m.newDerivedElmRef(mod+"Json", "Json", "decode"+asType),
+		m.newDerivedElmRef(mod+"Json", "Json", "encode"+asType),
+		m.newDerivedElmRef(mod+"Random", "Random", "random"+asType),
+		m.newDerivedElmRef(mod+"View", "View", "view"+asType),
+		m.newDerivedElmRef(mod+"Explorer", "Explorer", "form"+asType)}
 }
 
 // Converts an Elm reference to Elm code. If local, drops the module.
@@ -23,8 +23,9 @@ func TestFindImports(t *testing.T) {
 		}
 	`)
 	elm := NewModule("", FilesToPackages(plugin.Files)[0])
-	assert.Equal(t, []string{"Bytes", "Dict", "FindTests", importElmer,
-		importElmerTests}, elm.Imports)
+	assert.Equal(t, []string{"Bytes", "Dict", "FindExplorer", "FindJson", "FindRandom",
+		"FindTests", "FindView", importElmer, importElmerExplorer, importElmerJSON,
+		importElmerRandom, importElmerTests, importElmerView}, elm.Imports)
 }
 
 func TestFindImportsNested(t *testing.T) {
@@ -49,8 +50,10 @@ func TestFindImportsNested(t *testing.T) {
 		}
 	`)
 	elm := NewModule("", FilesToPackages(plugin.Files)[1])
-	assert.Equal(t, []string{"Bytes", "MyTests", "Other", "OtherTests",
-		importElmer, importElmerTests}, elm.Imports)
+	assert.Equal(t, []string{"Bytes", "MyExplorer", "MyJson", "MyRandom", "MyTests", "MyView",
+		"Other", "OtherExplorer", "OtherJson", "OtherRandom", "OtherTests", "OtherView",
+		importElmer, importElmerExplorer, importElmerJSON, importElmerRandom, importElmerTests,
+		importElmerView}, elm.Imports)
 }
 
 func TestImports(t *testing.T) {
@@ -67,8 +70,10 @@ func TestImports(t *testing.T) {
 			int32 b = 2;
 			int32 c = 3;
 		}`)
-	assert.Equal(t, []string{"AnotherPkg", "AnotherPkgTests", importElmerTests,
-		"XTests"}, elm.Imports)
+	assert.Equal(t, []string{"AnotherPkg", "AnotherPkgExplorer", "AnotherPkgJson",
+		"AnotherPkgRandom", "AnotherPkgTests", "AnotherPkgView", importElmerExplorer,
+		importElmerJSON, importElmerRandom, importElmerTests, importElmerView, "XExplorer",
+		"XJson", "XRandom", "XTests", "XView"}, elm.Imports)
 	assert.Len(t, elm.Records, 1)
 	assert.Equal(t, "MyMessage", elm.Records[0].Type.ID)
 }
@@ -0,0 +1,218 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type (
+	// How bad a lint finding is. Errors won't generate valid Elm, warnings generate awkward Elm
+	LintSeverity int
+
+	// A problem found in a schema by Lint
+	LintFinding struct {
+		Rule     string
+		Severity LintSeverity
+		Desc     protoreflect.Descriptor // Where the problem is
+		Message  string
+	}
+)
+
+const (
+	LintWarning LintSeverity = iota
+	LintError
+)
+
+// Comment directive for suppressing findings on a schema element e.g., `// elmer-lint:ignore int64 recursive`
+const lintIgnore = "elmer-lint:ignore"
+
+func (s LintSeverity) String() string {
+	if s == LintError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Formats a finding like a compiler error: `file:line:column: severity: message (rule)`. Lines and columns are only known if the schema has source info
+func (f *LintFinding) String() string {
+	file := f.Desc.ParentFile()
+	loc := file.SourceLocations().ByDescriptor(f.Desc)
+	if loc.Path == nil { // No source info
+		return fmt.Sprintf("%s: %s: %s (%s)", file.Path(), f.Severity, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", file.Path(),
+		loc.StartLine+1, loc.StartColumn+1, f.Severity, f.Message, f.Rule)
+}
+
+// Checks a module for schema designs that don't suit Elm. Findings can be suppressed with an `elmer-lint:ignore <rule>` comment on the element they're reported on. Sorted by location
+func Lint(m *Module) []*LintFinding {
+	l := new(linter)
+	for _, u := range m.Unions {
+		l.escaped(u.Comments, u.Desc, lintRelativeIdent(u.Desc))
+		values := u.Desc.Values()
+		for _, v := range u.Variants {
+			vd := values.ByNumber(v.Number)
+			l.escaped(v.Comments, vd, lintRelativeIdent(vd))
+		}
+		if label := strings.ToUpper(u.Default().Label); !strings.HasSuffix(label, "UNSPECIFIED") &&
+			!strings.HasSuffix(label, "UNKNOWN") {
+			l.report(u.Comments, "enum-zero", LintWarning, u.Desc,
+				"zero value %s is also used for unrecognised values, prefer an unspecified value e.g., %s_UNSPECIFIED",
+				u.Default().Label, strings.ToUpper(string(u.Desc.Name())))
+		}
+		// Aliases are in declaration order
+		var aliases int
+		seen := make(map[protoreflect.EnumNumber]bool)
+		for i := 0; i < values.Len(); i++ {
+			vd := values.Get(i)
+			if seen[vd.Number()] {
+				l.report(u.Aliases[aliases].Comments, "enum-alias", LintWarning, vd,
+					"%s is an alias of %s and becomes a value instead of a variant",
+					vd.Name(), u.Aliases[aliases].Variant.Label)
+				aliases++
+			}
+			seen[vd.Number()] = true
+		}
+	}
+
+	for _, r := range m.Records {
+		l.escaped(r.Comments, r.Desc, lintRelativeIdent(r.Desc))
+		if lintRecursive(r.Desc) {
+			l.report(r.Comments, "recursive", LintError, r.Desc,
+				"%s is recursive which makes an invalid Elm type alias", r.Desc.Name())
+		}
+		for _, f := range r.Fields {
+			fields := []*Field{f}
+			if f.Oneof != nil && !f.Oneof.IsSynthetic {
+				od := f.Oneof.Variants[0].Field.Desc.ContainingOneof()
+				l.escaped(f.Comments, od, string(od.Name()))
+				fields = nil
+				for _, v := range f.Oneof.Variants {
+					fields = append(fields, v.Field)
+				}
+			}
+			for _, f := range fields {
+				l.field(f)
+			}
+		}
+	}
+
+	for _, s := range m.Services {
+		for _, rpc := range s.Methods {
+			if rpc.IsStreaming() {
+				l.report(rpc.Comments, "streaming", LintWarning, rpc.Desc,
+					"streaming method %s is skipped by protoc-gen-elmer-twirp, see protoc-gen-elmer-streams", rpc.Method)
+			}
+		}
+	}
+
+	findings := l.findings
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i].Desc, findings[j].Desc
+		if a.ParentFile().Path() != b.ParentFile().Path() {
+			return a.ParentFile().Path() < b.ParentFile().Path()
+		}
+		return a.ParentFile().SourceLocations().ByDescriptor(a).StartLine <
+			b.ParentFile().SourceLocations().ByDescriptor(b).StartLine
+	})
+	return findings
+}
+
+type linter struct {
+	findings []*LintFinding
+}
+
+// Adds a finding unless the element's comments suppress it
+func (l *linter) report(comments *CommentSet, rule string, severity LintSeverity, desc protoreflect.Descriptor, formatter string, args ...interface{}) {
+	if !comments.ignores(rule) {
+		l.findings = append(l.findings, &LintFinding{rule, severity, desc, fmt.Sprintf(formatter, args...)})
+	}
+}
+
+// Reports an ident that needs an "X" prefix in Elm
+func (l *linter) escaped(comments *CommentSet, desc protoreflect.Descriptor, ident string) {
+	if asType, asValue, ok := protoIdentToEscapedElmID(ident); ok {
+		l.report(comments, "escaped-name", LintWarning, desc,
+			"%s is escaped to %s / %s in Elm", desc.Name(), asType, asValue)
+	}
+}
+
+func (l *linter) field(f *Field) {
+	fd := f.Desc
+	l.escaped(f.Comments, fd, string(fd.Name()))
+	kinds := []protoreflect.FieldDescriptor{fd}
+	if fd.IsMap() {
+		kinds = []protoreflect.FieldDescriptor{fd.MapKey(), fd.MapValue()}
+	}
+	for _, kd := range kinds {
+		switch kd.Kind() {
+		case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+			protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+			l.report(f.Comments, "int64", LintError, fd,
+				"%s is a 64-bit integer which Elm can't represent", fd.Name())
+		}
+	}
+}
+
+// Returns a descriptor's full ident without its package i.e., what its Elm ID is derived from
+func lintRelativeIdent(d protoreflect.Descriptor) string {
+	return strings.TrimPrefix(string(d.FullName()), string(d.ParentFile().Package())+".")
+}
+
+// Returns true if a message can contain itself. Well-known types are ignored, they're provided by our library
+func lintRecursive(md protoreflect.MessageDescriptor) bool {
+	seen := make(map[protoreflect.FullName]bool)
+	var visit func(protoreflect.MessageDescriptor) bool
+	visit = func(cur protoreflect.MessageDescriptor) bool {
+		fields := cur.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if fd.IsMap() {
+				fd = fd.MapValue()
+			}
+			sub := fd.Message()
+			if sub == nil || strings.HasPrefix(string(sub.FullName()), "google.protobuf.") {
+				continue
+			}
+			if sub.FullName() == md.FullName() {
+				return true
+			}
+			if !seen[sub.FullName()] {
+				seen[sub.FullName()] = true
+				if visit(sub) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return visit(md)
+}
+
+// Returns true if a comment suppresses a lint rule
+func (set *CommentSet) ignores(rule string) bool {
+	for _, c := range []Comments{set.Leading, set.Trailing} {
+		for _, line := range strings.Split(string(c), "\n") {
+			words := strings.Fields(line)
+			if len(words) == 0 || words[0] != lintIgnore {
+				continue
+			}
+			for _, word := range words[1:] {
+				if word == rule {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	plugin := testPlugin(t, `
+		syntax = "proto3";
+		package test.lint;
+
+		enum Colour {
+			option allow_alias = true;
+			RED = 0;
+			GREEN = 1;
+			VERDE = 1;
+			TRUE = 2;
+		}
+		enum Size {
+			SIZE_UNSPECIFIED = 0;
+		}
+		message Sub {
+			int64 big = 1;
+			map<bool, string> flags = 2;
+			string type = 3; // elmer-lint:ignore escaped-name
+			Sub next = 4;
+			oneof choice { int32 a = 5; uint64 b = 6; }
+		}
+		// elmer-lint:ignore recursive
+		message Node { repeated Node children = 1; }
+		service Svc {
+			rpc Watch(Empty) returns (stream Empty);
+		}
+		message Empty {}
+	`)
+	var findings []string
+	for _, pkg := range FilesToPackages(plugin.Files) {
+		if pkg.Generate {
+			for _, f := range Lint(NewModule("", pkg)) {
+				findings = append(findings, f.String())
+			}
+		}
+	}
+	assert.Equal(t, []string{
+		"test0.proto:5:17: warning: zero value RED is also used for unrecognised values, prefer an unspecified value e.g., COLOUR_UNSPECIFIED (enum-zero)",
+		"test0.proto:9:25: warning: VERDE is an alias of GREEN and becomes a value instead of a variant (enum-alias)",
+		"test0.proto:10:25: warning: TRUE is escaped to XTrue / xtrue in Elm (escaped-name)",
+		"test0.proto:15:17: warning: Sub is escaped to XSub / xsub in Elm (escaped-name)",
+		"test0.proto:15:17: error: Sub is recursive which makes an invalid Elm type alias (recursive)",
+		"test0.proto:16:25: error: big is a 64-bit integer which Elm can't represent (int64)",
+		"test0.proto:20:53: error: b is a 64-bit integer which Elm can't represent (int64)",
+		"test0.proto:25:25: warning: streaming method Watch is skipped by protoc-gen-elmer-twirp, see protoc-gen-elmer-streams (streaming)",
+	}, findings)
+}
+
+func TestLintNoSourceInfo(t *testing.T) {
+	f := &LintFinding{"streaming", LintWarning, testHTTPMethod(t, nil), "oops"}
+	assert.Equal(t, "http.proto: warning: oops (streaming)", f.String())
+}
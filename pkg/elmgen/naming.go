@@ -94,6 +94,12 @@ func protoPkgToElmModule(pkg string) string {
 
 // Converts a proto ident to an Elm type and value
 func protoIdentToElmID(ident string) (asType, asValue string) {
+	asType, asValue, _ = protoIdentToEscapedElmID(ident)
+	return
+}
+
+// Like protoIdentToElmID but also reports whether the IDs were prefixed with an "X" to make them valid Elm
+func protoIdentToEscapedElmID(ident string) (asType, asValue string, escaped bool) {
 	parts := protoIdentToElmCasing(ident)
 	asType = strings.Join(parts, "_")
 	// Lowercase first rune
@@ -104,6 +110,7 @@ func protoIdentToElmID(ident string) (asType, asValue string) {
 		reservedWord(asType) || reservedWord(asValue) {
 		asType = "X" + asType
 		asValue = "x" + asValue
+		escaped = true
 	}
 	return
 }
@@ -0,0 +1,75 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandom(t *testing.T) {
+	testElmFiles = map[string]string{"Test/RandomUsageTests.elm": `
+module Test.RandomUsageTests exposing (suite)
+
+import Expect
+import Fuzz
+import Protobuf.Decode as PD
+import Protobuf.ElmerRandom as ElmerRandom
+import Protobuf.Encode as PE
+import Random
+import Test exposing (Test, describe, fuzz, test)
+import Test.Random as T
+import Test.RandomRandom as R
+
+
+suite : Test
+suite =
+    let
+        config =
+            { maxList = 2, maxString = 4, alphabet = "xy" }
+
+        generate seed =
+            Tuple.first (Random.step (R.randomSample config) (Random.initialSeed seed))
+    in
+    describe "random generators"
+        [ fuzz Fuzz.int "encode then decode" <|
+            \seed ->
+                generate seed
+                    |> (\v -> PE.encode (T.encodeSample v) |> PD.decode T.decodeSample |> Maybe.map (T.equalSample v))
+                    |> Expect.equal (Just True)
+        , fuzz Fuzz.int "respects config" <|
+            \seed ->
+                generate seed
+                    |> (\v -> ( List.length v.tags <= 2, List.all (String.all (\c -> c == 'x' || c == 'y')) v.tags ))
+                    |> Expect.equal ( True, True )
+        , test "stable for a seed" <|
+            \_ -> generate 1 |> T.equalSample (generate 1) |> Expect.equal True
+        , test "default config" <|
+            \_ -> ElmerRandom.defaultConfig.maxList |> Expect.equal 3
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.random;
+		enum Colour { COLOUR_UNSPECIFIED = 0; RED = 1; }
+		message Sample {
+			repeated string tags = 1;
+			map<bool, Colour> flags = 2;
+			optional uint32 rating = 3;
+			oneof owner { string person = 4; Colour paint = 5; }
+		}`)
+	content := string(testFileContents["Test/RandomRandom.elm"])
+	assert.Contains(t, content, "randomSample : Protobuf.ElmerRandom.Config -> Generator Test.Random.Sample")
+	assert.Contains(t, content, "randomSample_Owner : Protobuf.ElmerRandom.Config -> Generator Test.Random.Sample_Owner")
+	assert.Contains(t, content, "randomColour : Protobuf.ElmerRandom.Config -> Generator Test.Random.Colour")
+	assert.Contains(t, content, "Protobuf.ElmerRandom.assoc config Protobuf.Elmer.boolToKey")
+	assert.NotContains(t, content, "Fuzz")
+}
@@ -36,6 +36,7 @@ func (m *Module) newRecord(msg *protogen.Message) *Record {
 	md := msg.Desc
 	var record Record
 	record.Type = m.NewElmType(md.ParentFile(), md)
+	record.Desc = md
 	record.Comments = newCommentSet(msg.Comments)
 	oneofsSeen := make(map[protoreflect.FullName]bool)
 
@@ -86,8 +87,13 @@ func (m *Module) newOneofField(protoOneof *protogen.Oneof) (*Oneof, *Field) {
 	return oneof, field
 }
 
-func (r *Record) Oneofs() (fields []*Field) {
-	for _, f := range r.Fields {
+func (r *Record) Oneofs() []*Field {
+	return oneofsOf(r.Fields)
+}
+
+// Filters fields to those holding a Oneof
+func oneofsOf(all []*Field) (fields []*Field) {
+	for _, f := range all {
 		if o := f.Oneof; o != nil {
 			fields = append(fields, f)
 		}
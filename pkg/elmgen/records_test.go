@@ -8,6 +8,7 @@
 package elmgen
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,8 +37,9 @@ func TestScalarRecord(t *testing.T) {
 			bytes type = 15;
 		}
 	`)
-	assert.Equal(t, []string{"Bytes", importElmer, importElmerTests,
-		"Test.ScalarTests"}, elm.Imports)
+	assert.Equal(t, []string{"Bytes", importElmer, importElmerExplorer, importElmerJSON,
+		importElmerRandom, importElmerTests, importElmerView, "Test.ScalarExplorer",
+		"Test.ScalarJson", "Test.ScalarRandom", "Test.ScalarTests", "Test.ScalarView"}, elm.Imports)
 	assert.Empty(t, elm.Unions)
 	assert.Len(t, elm.Records, 1)
 	scalar := elm.Records[0]
@@ -111,31 +113,33 @@ func TestFieldErrors(t *testing.T) {
 		package test.oops;
 		message Oops {
 			int64 my_int64 = 1;
+			map<string, uint64> my_map = 2;
 		}`)
+	elm := NewModule("", FilesToPackages(plugin.Files)[0])
 	field := plugin.Files[0].Messages[0].Fields[0]
 	assert.Equal(t, "my_int64", string(field.Desc.Name()))
-	// Field type
-	assert.Panics(t, func() {
-		fieldTypeDesc(nil, field.Desc)
-	})
-	// Field zero
-	assert.Panics(t, func() {
-		fieldZero(nil, field.Desc)
-	})
-	// Field codec
-	assert.Panics(t, func() {
-		fieldCodecKind(nil, "PE.", field.Desc)
-	})
-	// Field fuzzer
-	assert.Panics(t, func() {
-		fieldFuzzer(nil, field.Desc)
-	})
-	// General error path (fails on fieldType)
-	assert.Panics(t, func() {
-		elm := NewModule("", FilesToPackages(plugin.Files)[0])
-		g := plugin.NewGeneratedFile("file", "")
-		GenerateCodec(elm, g)
-	})
+	// Each path records the same error once
+	fieldTypeDesc(elm, field.Desc)
+	fieldZero(elm, field.Desc)
+	fieldCodecKind(elm, "PE.", field.Desc)
+	fieldFuzzer(elm, field.Desc)
+	fieldJSONDecoder(elm, field.Desc)
+	assert.EqualError(t, elm.Err(),
+		"test0.proto:5:25: test.oops.Oops.my_int64: 64-bit integers (int64) are unsupported by Elm")
+
+	// General error path collects every field. Map values are reported on their map
+	elm = NewModule("", FilesToPackages(plugin.Files)[0])
+	g := plugin.NewGeneratedFile("file", "")
+	GenerateCodec(elm, g)
+	assert.EqualError(t, elm.Err(), strings.Join([]string{
+		"test0.proto:5:25: test.oops.Oops.my_int64: 64-bit integers (int64) are unsupported by Elm",
+		"test0.proto:6:25: test.oops.Oops.my_map: 64-bit integers (uint64) are unsupported by Elm",
+	}, "\n"))
+}
+
+func TestErrorNoSourceInfo(t *testing.T) {
+	err := &Error{testHTTPMethod(t, nil), "oops"}
+	assert.EqualError(t, err, "http.proto: http.Svc.Do: oops")
 }
 
 func TestListField(t *testing.T) {
@@ -170,6 +174,23 @@ func TestMapField(t *testing.T) {
 	// Can we mimic a map entry? No. https://developers.google.com/protocol-buffers/docs/proto3#backwards_compatibility
 }
 
+func TestMapBoolKeys(t *testing.T) {
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.map.bool;
+		message A {
+			map<bool, string> flags = 1;
+			map<bool, B> nested = 2;
+		}
+		message B {}`)
+	assert.Len(t, elm.Records, 2)
+	a := elm.Records[0]
+	assert.Equal(t, "A", a.Type.ID)
+	// Elm's Dict can't hold Bool keys
+	assert.Equal(t, "(List ( Bool, String ))", fieldType(elm, a.Fields[0]))
+	assert.Equal(t, "(List ( Bool, B ))", fieldType(elm, a.Fields[1]))
+}
+
 func TestOneOf(t *testing.T) {
 	elm := testModule(t, `
 		syntax = "proto3";
@@ -0,0 +1,194 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Field number of the `google.api.http` extension on `google.protobuf.MethodOptions`. We read it off the wire rather than depending on genproto
+const httpRuleExtension protowire.Number = 72295728
+
+type (
+	// A `google.api.HttpRule` mapping an RPC to a REST endpoint. Only the primary binding is kept; additional bindings are ignored
+	HTTPRule struct {
+		Method, Path       string
+		Body, ResponseBody string
+	}
+
+	// A piece of a path template. Either literal text or a variable bound to a (possibly nested) field of the input message
+	PathSegment struct {
+		Literal string
+		Fields  []protoreflect.FieldDescriptor
+		Multi   bool // Variable may span more than one path segment e.g., `{name=shelves/*}`
+	}
+)
+
+// Reads the `google.api.http` option from a method. Returns nil if not set
+func methodHTTPRule(md protoreflect.MethodDescriptor) (*HTTPRule, error) {
+	// Unregistered extensions end up as unknown fields. Marshalling covers both cases
+	b, err := proto.Marshal(md.Options())
+	if err != nil {
+		return nil, err
+	}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+		if num == httpRuleExtension && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				break
+			}
+			return parseHTTPRule(v)
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+	}
+	return nil, nil
+}
+
+// Parses a wire encoded `google.api.HttpRule`
+func parseHTTPRule(b []byte) (*HTTPRule, error) {
+	rule := new(HTTPRule)
+	err := consumeStrings(b, func(num protowire.Number, v []byte) error {
+		switch num {
+		case 2:
+			rule.Method, rule.Path = "GET", string(v)
+		case 3:
+			rule.Method, rule.Path = "PUT", string(v)
+		case 4:
+			rule.Method, rule.Path = "POST", string(v)
+		case 5:
+			rule.Method, rule.Path = "DELETE", string(v)
+		case 6:
+			rule.Method, rule.Path = "PATCH", string(v)
+		case 7:
+			rule.Body = string(v)
+		case 8: // CustomHttpPattern
+			return consumeStrings(v, func(num protowire.Number, v []byte) error {
+				switch num {
+				case 1:
+					rule.Method = string(v)
+				case 2:
+					rule.Path = string(v)
+				}
+				return nil
+			})
+		case 12:
+			rule.ResponseBody = string(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rule.Method == "" || rule.Path == "" {
+		return nil, fmt.Errorf("google.api.http has no pattern")
+	}
+	return rule, nil
+}
+
+// Calls f for each length-delimited field of a message. Other fields are skipped
+func consumeStrings(b []byte, f func(protowire.Number, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := f(num, v); err != nil {
+				return err
+			}
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// Parses a path template e.g., "/v1/{name=shelves/*}/books/{book.id}" resolving variables against the input message. Variables must be singular scalars (or enums) reached via singular messages
+func parsePathTemplate(path string, md protoreflect.MessageDescriptor) ([]*PathSegment, error) {
+	var segments []*PathSegment
+	for path != "" {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			segments = append(segments, &PathSegment{Literal: path})
+			break
+		}
+		if start > 0 {
+			segments = append(segments, &PathSegment{Literal: path[:start]})
+		}
+		end := strings.IndexByte(path, '}')
+		if end < start {
+			return nil, fmt.Errorf("unclosed variable in path template: %s", path)
+		}
+		variable := path[start+1 : end]
+		path = path[end+1:]
+
+		// Split field path from pattern
+		fieldPath, pattern := variable, "*"
+		if eq := strings.IndexByte(variable, '='); eq >= 0 {
+			fieldPath, pattern = variable[:eq], variable[eq+1:]
+		}
+		fields, err := resolveFieldPath(fieldPath, md)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, &PathSegment{
+			Fields: fields,
+			Multi:  pattern != "*"})
+	}
+	return segments, nil
+}
+
+// Resolves a dotted field path e.g., "book.id" against a message
+func resolveFieldPath(fieldPath string, md protoreflect.MessageDescriptor) ([]protoreflect.FieldDescriptor, error) {
+	var fields []protoreflect.FieldDescriptor
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		if md == nil {
+			return nil, fmt.Errorf("field path %q goes through a non-message", fieldPath)
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("field path %q: %s has no field %q", fieldPath, md.FullName(), name)
+		}
+		if fd.IsList() || fd.IsMap() || fd.ContainingOneof() != nil {
+			return nil, fmt.Errorf("field path %q: %s must be singular and not part of a oneof", fieldPath, name)
+		}
+		isMsg := fd.Message() != nil
+		last := i == len(names)-1
+		if last && isMsg {
+			return nil, fmt.Errorf("field path %q must end in a scalar", fieldPath)
+		}
+		fields = append(fields, fd)
+		md = fd.Message()
+	}
+	return fields, nil
+}
@@ -0,0 +1,268 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Builds a method with a wire encoded `google.api.http` option
+func testHTTPMethod(t *testing.T, rule []byte) protoreflect.MethodDescriptor {
+	opts := new(descriptorpb.MethodOptions)
+	if rule != nil {
+		var ext []byte
+		ext = protowire.AppendTag(ext, httpRuleExtension, protowire.BytesType)
+		ext = protowire.AppendBytes(ext, rule)
+		opts.ProtoReflect().SetUnknown(ext)
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("http.proto"),
+		Package:     proto.String("http"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Msg")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Svc"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Do"),
+				InputType:  proto.String(".http.Msg"),
+				OutputType: proto.String(".http.Msg"),
+				Options:    opts}}}}}
+	fd, err := protodesc.NewFile(fdp, nil)
+	assert.NoError(t, err)
+	return fd.Services().Get(0).Methods().Get(0)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func TestHTTPRule(t *testing.T) {
+	rule, err := methodHTTPRule(testHTTPMethod(t, nil))
+	assert.NoError(t, err)
+	assert.Nil(t, rule)
+
+	var opt []byte
+	opt = appendString(opt, 1, "http.Svc.Do") // Selector is ignored
+	opt = appendString(opt, 6, "/v1/{name}")
+	opt = appendString(opt, 7, "*")
+	opt = appendString(opt, 12, "result")
+	rule, err = methodHTTPRule(testHTTPMethod(t, opt))
+	assert.NoError(t, err)
+	assert.Equal(t, &HTTPRule{"PATCH", "/v1/{name}", "*", "result"}, rule)
+
+	var custom []byte
+	custom = appendString(custom, 1, "HEAD")
+	custom = appendString(custom, 2, "/v1/things")
+	opt = protowire.AppendTag(nil, 8, protowire.BytesType)
+	opt = protowire.AppendBytes(opt, custom)
+	rule, err = methodHTTPRule(testHTTPMethod(t, opt))
+	assert.NoError(t, err)
+	assert.Equal(t, &HTTPRule{Method: "HEAD", Path: "/v1/things"}, rule)
+
+	// No pattern
+	_, err = methodHTTPRule(testHTTPMethod(t, appendString(nil, 7, "*")))
+	assert.Error(t, err)
+}
+
+func TestPathTemplate(t *testing.T) {
+	// Use a descriptor we know: FieldDescriptorProto.options.packed
+	md := (&descriptorpb.FieldDescriptorProto{}).ProtoReflect().Descriptor()
+	segments, err := parsePathTemplate("/v1/{name}/opts/{options.packed=a/**}:get", md)
+	assert.NoError(t, err)
+	assert.Len(t, segments, 5)
+	assert.Equal(t, "/v1/", segments[0].Literal)
+	assert.Equal(t, protoreflect.Name("name"), segments[1].Fields[0].Name())
+	assert.False(t, segments[1].Multi)
+	assert.Equal(t, "/opts/", segments[2].Literal)
+	assert.Len(t, segments[3].Fields, 2)
+	assert.Equal(t, protoreflect.Name("packed"), segments[3].Fields[1].Name())
+	assert.True(t, segments[3].Multi)
+	assert.Equal(t, ":get", segments[4].Literal)
+
+	for _, bad := range []string{
+		"/v1/{missing}",                      // Unknown field
+		"/v1/{options}",                      // Must end in a scalar
+		"/v1/{name.nope}",                    // Through a non-message
+		"/v1/{name",                          // Unclosed
+		"/v1/{options.uninterpreted_option}", // Repeated
+	} {
+		_, err := parsePathTemplate(bad, md)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestREST(t *testing.T) {
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.library;
+		import "google/api/annotations.proto";
+
+		service Library {
+			rpc GetBook(GetBookReq) returns (Book) {
+				option (google.api.http) = { get: "/v1/{name=shelves/*/books/*}" };
+			}
+			rpc ListBooks(ListBooksReq) returns (ListBooksResp) {
+				option (google.api.http) = { get: "/v1/shelves/{shelf.id}/books" };
+			}
+			rpc CreateBook(CreateBookReq) returns (Book) {
+				option (google.api.http) = {
+					post: "/v1/shelves/{shelf}/books"
+					body: "book"
+				};
+			}
+			rpc UpdateBook(Book) returns (Book) {
+				option (google.api.http) = { patch: "/v1/{name}" body: "*" };
+			}
+			rpc TitleOf(GetBookReq) returns (Book) {
+				option (google.api.http) = {
+					get: "/v1/{name}/title"
+					response_body: "title"
+				};
+			}
+			rpc NotAnnotated(GetBookReq) returns (Book);
+		}
+
+		enum Genre {
+			FICTION = 0;
+			NON_FICTION = 1;
+		}
+		message Shelf { int32 id = 1; string theme = 2; }
+		message Book {
+			string name = 1;
+			string title = 2;
+			Genre genre = 3;
+			repeated string authors = 4;
+			bytes cover = 5;
+			map<string, int32> ratings = 6;
+			oneof kind {
+				float price = 7;
+				bool free = 8;
+			}
+			optional double weight = 9;
+		}
+		message GetBookReq { string name = 1; }
+		message ListBooksReq {
+			Shelf shelf = 1;
+			int32 page_size = 2;
+			repeated Genre genres = 3;
+			optional bool in_print = 4;
+			map<string, string> ignored = 5;
+		}
+		message ListBooksResp { repeated Book books = 1; }
+		message CreateBookReq {
+			string shelf = 1;
+			Book book = 2;
+			bool validate_only = 3;
+		}
+	`)
+	assert.Len(t, elm.Services, 1)
+	for _, rpc := range elm.Services[0].Methods {
+		if rpc.Method == "NotAnnotated" {
+			assert.Nil(t, rpc.HTTP)
+		} else {
+			assert.NotNil(t, rpc.HTTP, rpc.Method)
+		}
+	}
+
+	json := string(testFileContents["Test/LibraryJson.elm"])
+	assert.Contains(t, json, "decodeBook : JD.Decoder Test.Library.Book")
+	assert.Contains(t, json, "encodeBook : Test.Library.Book -> JE.Value")
+	assert.Contains(t, json, `"pageSize"`)
+	assert.Contains(t, json, `"page_size"`)
+
+	rest := string(testFileContents["Test/LibraryRest.elm"])
+	assert.Contains(t, rest, "Skipped test.library.Library.NotAnnotated")
+	assert.NotContains(t, rest, "restLibrary_NotAnnotated :")
+	for _, method := range []string{"GetBook", "ListBooks", "CreateBook", "UpdateBook", "TitleOf"} {
+		assert.Contains(t, rest, "restLibrary_"+method+" :")
+	}
+	// Paths
+	assert.Contains(t, rest, "Protobuf.ElmerRest.segments data.name")
+	assert.Contains(t, rest, "Protobuf.ElmerRest.segment (String.fromInt data.shelf.id)")
+	// Query: unbound fields only
+	assert.Contains(t, rest, `Protobuf.ElmerRest.query "shelf.theme" identity data.shelf.theme`)
+	assert.Contains(t, rest, `Protobuf.ElmerRest.query "page_size" String.fromInt data.pageSize`)
+	assert.Contains(t, rest, `Protobuf.ElmerRest.queryList "genres" Test.Library.fromGenre data.genres`)
+	assert.Contains(t, rest, `Protobuf.ElmerRest.queryMaybe "in_print" Protobuf.ElmerRest.fromBool data.inPrint`)
+	assert.Contains(t, rest, `Protobuf.ElmerRest.query "validate_only" Protobuf.ElmerRest.fromBool data.validateOnly`)
+	assert.NotContains(t, rest, `"ignored"`)
+	assert.NotContains(t, rest, `"shelf.id"`)
+	// Bodies
+	assert.Contains(t, rest, "Http.jsonBody (Test.LibraryJson.encodeBook data.book)")
+	assert.Contains(t, rest, "Http.jsonBody (Test.LibraryJson.encodeBook data)")
+	assert.Contains(t, rest, "Http.emptyBody")
+	assert.Contains(t, rest, "{ zero | title = v }")
+}
+
+func TestRESTUnsupported(t *testing.T) {
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.registry;
+		import "google/api/annotations.proto";
+		import "google/protobuf/any.proto";
+		import "google/protobuf/wrappers.proto";
+
+		service Registry {
+			rpc Find(Node) returns (Found) {
+				option (google.api.http) = { get: "/v1/nodes/{name}" };
+			}
+		}
+
+		message Node {
+			string name = 1;
+			Node parent = 2;
+		}
+		message Found { google.protobuf.Int64Value size = 1; }
+		message Packed { google.protobuf.Any detail = 1; }
+		message Wrapper { repeated Packed packed = 1; }
+	`)
+	assert.NoError(t, elm.Err())
+
+	json := string(testFileContents["Test/RegistryJson.elm"])
+	assert.Contains(t, json, "-- Skipped test.registry.Packed: well-known type google.protobuf.Any has no JSON codec")
+	assert.Contains(t, json, "-- Skipped test.registry.Wrapper: well-known type google.protobuf.Any has no JSON codec")
+	assert.NotContains(t, json, "decodePacked :")
+	assert.NotContains(t, json, "encodeWrapper :")
+	// 64-bit wrappers are strings in JSON
+	assert.Contains(t, json, "Protobuf.ElmerJson.encodeInt64Value v.size")
+
+	// Recursive messages are only queried once
+	rest := string(testFileContents["Test/RegistryRest.elm"])
+	assert.NotContains(t, rest, `"parent.name"`)
+	assert.NotContains(t, rest, `"parent.parent`)
+
+	// Methods reaching a well-known type without a codec are an error
+	plugin := testPlugin(t, `
+		syntax = "proto3";
+		package test.oops;
+		import "google/api/annotations.proto";
+		import "google/protobuf/any.proto";
+
+		service Oops {
+			rpc Get(Req) returns (Resp) {
+				option (google.api.http) = { get: "/v1/oops" };
+			}
+		}
+		message Req {}
+		message Resp { google.protobuf.Any detail = 1; }
+	`)
+	pkgs := FilesToPackages(plugin.Files) // Dependencies come first
+	elm = NewModule("Rest", pkgs[len(pkgs)-1])
+	assert.False(t, GenerateREST(elm, plugin.NewGeneratedFile("file", "")))
+	assert.True(t, strings.HasSuffix(elm.Err().Error(),
+		"test.oops.Oops.Get: well-known type google.protobuf.Any has no JSON codec"))
+}
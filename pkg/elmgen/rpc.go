@@ -9,11 +9,15 @@ package elmgen
 
 import (
 	"sort"
+	"strings"
 
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// Default prefix of an RPC's ID
+const rpcPrefix = "twirp"
+
 // Adds RPCs to a an Elm module from proto services
 func (m *Module) addRPCs(services []*protogen.Service) {
 	for _, protoService := range services {
@@ -37,13 +41,87 @@ func (m *Module) addRPCs(services []*protogen.Service) {
 func (m *Module) newRPC(sd protoreflect.Descriptor, method *protogen.Method) *RPC {
 	md := method.Desc
 	in, out := md.Input(), md.Output()
+	rule, err := methodHTTPRule(md)
+	if err != nil {
+		m.errorf(md, "google.api.http: %s", err)
+	}
 	return &RPC{
-		m.NewElmValue(md.ParentFile(), "twirp", md),
+		m.NewElmValue(md.ParentFile(), rpcPrefix, md),
 		m.NewElmType(in.ParentFile(), in),
 		m.NewElmType(out.ParentFile(), out),
 		md.IsStreamingClient(), md.IsStreamingServer(),
+		isEmpty(in), isEmpty(out),
 
 		sd.FullName(),
 		md.Name(),
+		rule,
+		newPagination(in, out),
+		md,
 		newCommentSet(method.Comments)}
 }
+
+// Detects AIP-158 pagination: `page_size` and `page_token` on the request, `next_page_token` on the response. The response's first repeated field holds the results. Returns nil if the convention isn't followed
+func newPagination(in, out protoreflect.MessageDescriptor) *Pagination {
+	field := func(md protoreflect.MessageDescriptor, name protoreflect.Name, kind protoreflect.Kind) protoreflect.FieldDescriptor {
+		fd := md.Fields().ByName(name)
+		if fd == nil || fd.Kind() != kind || fd.IsList() || fd.ContainingOneof() != nil {
+			return nil
+		}
+		return fd
+	}
+	pages := &Pagination{
+		field(in, "page_size", protoreflect.Int32Kind),
+		field(in, "page_token", protoreflect.StringKind),
+		field(out, "next_page_token", protoreflect.StringKind),
+		nil}
+	fields := out.Fields()
+	for i := 0; i < fields.Len() && pages.Results == nil; i++ {
+		if fd := fields.Get(i); fd.IsList() {
+			pages.Results = fd
+		}
+	}
+	if pages.PageSize == nil || pages.PageToken == nil ||
+		pages.NextPageToken == nil || pages.Results == nil {
+		return nil
+	}
+	return pages
+}
+
+// Returns true if a message is google.protobuf.Empty
+func isEmpty(md protoreflect.MessageDescriptor) bool {
+	return md.FullName() == "google.protobuf.Empty"
+}
+
+// Returns the Elm type of an RPC's response. Empty becomes the unit type
+func (rpc *RPC) OutType() string {
+	if rpc.OutEmpty {
+		return "()"
+	}
+	return rpc.Out.String()
+}
+
+// Returns the signature (with a leading arrow) and parameter (with a leading space) of an RPC's request. Empty has neither
+func (rpc *RPC) InParam() (signature, param string) {
+	if rpc.InEmpty {
+		return "", ""
+	}
+	return " -> " + rpc.In.String(), " data"
+}
+
+// Returns the Elm expression of an RPC's request. Empty is always a zero value
+func (rpc *RPC) InData() string {
+	if rpc.InEmpty {
+		return rpc.In.Zero.String()
+	}
+	return "data"
+}
+
+// Returns true if either the client or server streams
+func (rpc *RPC) IsStreaming() bool {
+	return rpc.InStreaming || rpc.OutStreaming
+}
+
+// Returns the RPC's ID with a different prefix. Used by generators that need more than one function per RPC e.g., "open" instead of "twirp"
+func (rpc *RPC) IDWithPrefix(prefix string) string {
+	return prefix + strings.TrimPrefix(rpc.ID.ID, rpcPrefix)
+}
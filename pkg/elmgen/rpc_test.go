@@ -64,3 +64,242 @@ func TestRPCWithComments(t *testing.T) {
 	assert.True(t, strings.Contains(content, "method comment 1"))
 	assert.True(t, strings.Contains(content, "method comment 2"))
 }
+
+func TestRPCStreaming(t *testing.T) {
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.streaming;
+		service Streamer {
+			rpc Unary(Req) returns (Resp);
+			rpc Server(Req) returns (stream Resp);
+			rpc Client(stream Req) returns (Resp);
+			rpc Both(stream Req) returns (stream Resp);
+		}
+		message Req {}
+		message Resp {}
+	`)
+	assert.Len(t, elm.Services, 1)
+	methods := elm.Services[0].Methods
+	assert.Len(t, methods, 4)
+	for _, exp := range []struct {
+		ID      string
+		In, Out bool
+	}{
+		{"twirpStreamer_Both", true, true},
+		{"twirpStreamer_Client", true, false},
+		{"twirpStreamer_Server", false, true},
+		{"twirpStreamer_Unary", false, false},
+	} {
+		var rpc *RPC
+		for _, m := range methods {
+			if m.ID.ID == exp.ID {
+				rpc = m
+			}
+		}
+		assert.NotNil(t, rpc, exp.ID)
+		assert.Equal(t, exp.In, rpc.InStreaming, exp.ID)
+		assert.Equal(t, exp.Out, rpc.OutStreaming, exp.ID)
+		assert.Equal(t, exp.In || exp.Out, rpc.IsStreaming(), exp.ID)
+	}
+
+	// Twirp only has the unary method
+	twirp := string(testFileContents["Test/StreamingTwirp.elm"])
+	assert.Contains(t, twirp, "twirpStreamer_Unary")
+	assert.NotContains(t, twirp, "twirpStreamer_Server :")
+	assert.Contains(t, twirp, "Skipped test.streaming.Streamer.Both")
+	// Streams has everything but the unary method
+	streams := string(testFileContents["Test/StreamingStreams.elm"])
+	assert.Contains(t, streams, "port module Test.StreamingStreams")
+	assert.NotContains(t, streams, "Streamer_Unary")
+	for _, method := range []string{"Server", "Client", "Both"} {
+		for _, prefix := range []string{"open", "send", "close", "listen"} {
+			assert.Contains(t, streams, prefix+"Streamer_"+method+" :")
+		}
+		assert.Contains(t, streams, "port testStreamingStreams_Streamer_"+method+"Out :")
+		assert.Contains(t, streams, "port testStreamingStreams_Streamer_"+method+"In :")
+	}
+}
+
+func TestRPCEmpty(t *testing.T) {
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.empty;
+		import "google/protobuf/empty.proto";
+		import "google/api/annotations.proto";
+		service Pinger {
+			rpc Ping(google.protobuf.Empty) returns (google.protobuf.Empty) {
+				option (google.api.http) = { post: "/v1/ping" body: "*" };
+			}
+			rpc Echo(Msg) returns (google.protobuf.Empty);
+			rpc Get(google.protobuf.Empty) returns (Msg);
+			rpc Watch(google.protobuf.Empty) returns (stream google.protobuf.Empty);
+		}
+		message Msg { string text = 1; }
+	`)
+	assert.Len(t, elm.Services, 1)
+	for _, rpc := range elm.Services[0].Methods {
+		switch rpc.Method {
+		case "Ping", "Watch":
+			assert.True(t, rpc.InEmpty, rpc.Method)
+			assert.True(t, rpc.OutEmpty, rpc.Method)
+		case "Echo":
+			assert.False(t, rpc.InEmpty)
+			assert.True(t, rpc.OutEmpty)
+		case "Get":
+			assert.True(t, rpc.InEmpty)
+			assert.False(t, rpc.OutEmpty)
+		}
+	}
+
+	twirp := string(testFileContents["Test/EmptyTwirp.elm"])
+	assert.Contains(t, twirp, "twirpPinger_Ping msg api =")
+	assert.Contains(t, twirp, "twirpPinger_Echo msg api data =")
+	assert.Contains(t, twirp, "(Result Http.Error () -> msg)")
+	assert.Contains(t, twirp, "(Result Http.Error Test.Empty.Msg -> msg)")
+	assert.NotContains(t, twirp, "Google.Protobuf.Empty ->")
+
+	streams := string(testFileContents["Test/EmptyStreams.elm"])
+	assert.Contains(t, streams, "sendPinger_Watch handle =")
+	assert.Contains(t, streams, "Protobuf.ElmerStreams.Event () -> msg")
+
+	rest := string(testFileContents["Test/EmptyRest.elm"])
+	assert.Contains(t, rest, "restPinger_Ping msg api =")
+	assert.Contains(t, rest, "Http.expectWhatever msg")
+}
+
+func TestRPCMetadata(t *testing.T) {
+	testModule(t, `
+		syntax = "proto3";
+		package test.metadata;
+		service Tagged {
+			rpc Get(Req) returns (Resp);
+		}
+		message Req {}
+		message Resp { string etag = 1; }
+	`)
+	// Clients take a Protobuf.ElmerHttp.Config but plain results don't use its metadata
+	plain := string(testFileContents["Test/MetadataTwirp.elm"])
+	assert.Contains(t, plain, "taggedClient : String -> Protobuf.ElmerHttp.Config -> TaggedClient msg")
+	assert.NotContains(t, plain, "Protobuf.ElmerHttp.Response")
+	assert.NotContains(t, plain, "Protobuf.ElmerHttp.expectResponse")
+	assert.Contains(t, plain, "PD.expectBytes msg Test.Metadata.decodeResp")
+
+	twirp := string(testFileContents["Test/MetadataTwirpMetadata.elm"])
+	assert.Contains(t, twirp, "import Protobuf.ElmerHttp")
+	assert.Contains(t, twirp, "(Result Protobuf.ElmerHttp.Error (Protobuf.ElmerHttp.Response Test.Metadata.Resp) -> msg)")
+	assert.Contains(t, twirp, "Protobuf.ElmerHttp.expectResponse msg Test.Metadata.decodeResp")
+}
+
+func TestRPCEffects(t *testing.T) {
+	testModule(t, `
+		syntax = "proto3";
+		package test.effects;
+		import "google/protobuf/empty.proto";
+		service Haberdasher {
+			rpc MakeHat(Size) returns (Hat);
+			rpc Reset(google.protobuf.Empty) returns (google.protobuf.Empty);
+			rpc Watch(Size) returns (stream Hat);
+		}
+		message Size { int32 inches = 1; }
+		message Hat { int32 inches = 1; string color = 2; }
+	`)
+	plain := string(testFileContents["Test/EffectsTwirp.elm"])
+	assert.NotContains(t, plain, "type Effect")
+
+	effects := string(testFileContents["Test/EffectsTwirpEffects.elm"])
+	assert.Contains(t, effects, "import Protobuf.ElmerEffect")
+	assert.Contains(t, effects, "type Effect msg")
+	assert.Contains(t, effects, "Haberdasher_MakeHat (Result Protobuf.ElmerHttp.Error (Protobuf.ElmerHttp.Response Test.Effects.Hat) -> msg) Test.Effects.Size")
+	assert.Contains(t, effects, "Haberdasher_Reset (Result Protobuf.ElmerHttp.Error (Protobuf.ElmerHttp.Response ()) -> msg)\n")
+	assert.NotContains(t, effects, "Haberdasher_Watch")
+	assert.Contains(t, effects, `service = "test.effects.Haberdasher"`)
+	assert.Contains(t, effects, "msg << Protobuf.ElmerHttp.resolve Test.Effects.decodeHat")
+	assert.Contains(t, effects, "mapEffect : (a -> b) -> Effect a -> Effect b")
+	assert.Contains(t, effects, "perform : String -> Effect msg -> Cmd msg")
+}
+
+func TestRPCPagination(t *testing.T) {
+	elm := testModule(t, `
+		syntax = "proto3";
+		package test.pages;
+		service Library {
+			rpc ListBooks(ListBooksReq) returns (ListBooksResp);
+			rpc ListNames(ListBooksReq) returns (ListNamesResp);
+			rpc NoToken(ListBooksReq) returns (NoTokenResp);
+			rpc Streamed(ListBooksReq) returns (stream ListBooksResp);
+		}
+		message Book { string name = 1; }
+		message ListBooksReq {
+			string parent = 1;
+			int32 page_size = 2;
+			string page_token = 3;
+		}
+		message ListBooksResp {
+			repeated Book books = 1;
+			string next_page_token = 2;
+			repeated string skipped = 3;
+		}
+		message ListNamesResp {
+			string next_page_token = 1;
+			map<string, string> ignored = 2;
+			repeated string names = 3;
+		}
+		message NoTokenResp { repeated Book books = 1; }
+	`)
+	assert.Len(t, elm.Services, 1)
+	for _, rpc := range elm.Services[0].Methods {
+		switch rpc.Method {
+		case "ListBooks", "Streamed":
+			assert.NotNil(t, rpc.Pages, rpc.Method)
+			assert.Equal(t, protoreflect.Name("books"), rpc.Pages.Results.Name())
+		case "ListNames":
+			assert.NotNil(t, rpc.Pages)
+			assert.Equal(t, protoreflect.Name("names"), rpc.Pages.Results.Name())
+		case "NoToken":
+			assert.Nil(t, rpc.Pages)
+		}
+	}
+
+	twirp := string(testFileContents["Test/PagesTwirp.elm"])
+	assert.Contains(t, twirp, "taskLibrary_ListBooks : String -> Protobuf.ElmerHttp.Config -> Test.Pages.ListBooksReq -> Task Http.Error Test.Pages.ListBooksResp")
+	assert.Contains(t, twirp, "fetchAllLibrary_ListBooks maxPages api config =")
+	assert.Contains(t, twirp, "Just { data | pageToken = resp.nextPageToken }")
+	assert.Contains(t, twirp, "fetchAllLibrary_ListBooks : Int -> String -> Protobuf.ElmerHttp.Config -> Test.Pages.ListBooksReq -> Task Http.Error (List Test.Pages.Book)")
+	assert.Contains(t, twirp, "fetchAllLibrary_ListNames : Int -> String -> Protobuf.ElmerHttp.Config -> Test.Pages.ListBooksReq -> Task Http.Error (List String)")
+	assert.NotContains(t, twirp, "fetchAllLibrary_NoToken")
+	assert.NotContains(t, twirp, "fetchAllLibrary_Streamed")
+}
+
+func TestRPCClient(t *testing.T) {
+	testModule(t, `
+		syntax = "proto3";
+		package test.client;
+		import "google/protobuf/empty.proto";
+		service Haberdasher {
+			rpc MakeHat(Size) returns (Hat);
+			rpc Reset(google.protobuf.Empty) returns (google.protobuf.Empty);
+			rpc Watch(Size) returns (stream Hat);
+		}
+		service OnlyStreams {
+			rpc Watch(Size) returns (stream Hat);
+		}
+		message Size { int32 inches = 1; }
+		message Hat { int32 inches = 1; string color = 2; }
+	`)
+	twirp := string(testFileContents["Test/ClientTwirp.elm"])
+	assert.Contains(t, twirp, "type alias HaberdasherClient msg =")
+	assert.Contains(t, twirp, "makeHat : (Result Http.Error Test.Client.Hat -> msg) -> Test.Client.Size -> Cmd msg")
+	assert.Contains(t, twirp, "reset : (Result Http.Error () -> msg) -> Cmd msg")
+	assert.NotContains(t, twirp, "watch :")
+	assert.Contains(t, twirp, "haberdasherClient : String -> Protobuf.ElmerHttp.Config -> HaberdasherClient msg")
+	assert.Contains(t, twirp, "headers = config.headers")
+	assert.Contains(t, twirp, "type alias HaberdasherStub =")
+	assert.Contains(t, twirp, "makeHat : Test.Client.Size -> Result Http.Error Test.Client.Hat")
+	assert.Contains(t, twirp, "defaultHaberdasherStub : HaberdasherStub")
+	assert.Contains(t, twirp, "stubHaberdasher : HaberdasherStub -> HaberdasherClient msg")
+	// Nothing to call
+	assert.NotContains(t, twirp, "OnlyStreamsClient")
+
+	metadata := string(testFileContents["Test/ClientTwirpMetadata.elm"])
+	assert.Contains(t, metadata, "Ok (Protobuf.ElmerHttp.Response Protobuf.ElmerHttp.stubMetadata ())")
+}
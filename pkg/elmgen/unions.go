@@ -28,6 +28,7 @@ func (m *Module) newUnion(enum *protogen.Enum) *Union {
 	ed := enum.Desc
 	union := new(Union)
 	union.Type = m.NewElmType(ed.ParentFile(), ed)
+	union.Desc = ed
 	union.Comments = newCommentSet(enum.Comments)
 	// Add variants
 	aliases := make(map[protoreflect.EnumNumber]*Variant)
@@ -38,6 +39,7 @@ func (m *Module) newUnion(enum *protogen.Enum) *Union {
 		if original := aliases[num]; original != nil {
 			alias := &VariantAlias{
 				m.NewElmValue(vd.ParentFile(), "alias", vd),
+				string(vd.Name()),
 				original,
 				newCommentSet(value.Comments)}
 			union.Aliases = append(union.Aliases, alias)
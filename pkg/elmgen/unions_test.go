@@ -80,12 +80,57 @@ func TestUnionAllowAlias(t *testing.T) {
 	assert.Equal(t, "Unknown", alias.Variants[0].ID.ID)
 	assert.Equal(t, "Started", alias.Variants[1].ID.ID)
 	assert.Equal(t, "aliasRunning", alias.Aliases[0].Alias.ID)
+	assert.Equal(t, "RUNNING", alias.Aliases[0].Label)
 	assert.Equal(t, "Started", alias.Aliases[0].Variant.ID.String())
 	// Check comments
 	assert.Contains(t, alias.Variants[1].Comments.Trailing, "The original")
 	assert.Contains(t, alias.Aliases[0].Comments.Trailing, "This is the alias")
 }
 
+func TestUnionConversions(t *testing.T) {
+	testElmFiles = map[string]string{"Test/AnswersUsageTests.elm": `
+module Test.AnswersUsageTests exposing (suite)
+
+import Dict
+import Expect
+import Test exposing (Test, describe, test)
+import Test.Answers as A
+
+
+suite : Test
+suite =
+    describe "enum conversions"
+        [ test "alias-aware parsing" <|
+            \_ -> List.map A.toAnswer [ "YES", "ALIAS", "NOPE" ] |> Expect.equal [ A.Yes, A.Yes, A.AnswerUnspecified ]
+        , test "wire numbers" <|
+            \_ -> List.map A.fromIntAnswer A.valuesOfAnswer |> Expect.equal [ 0, 1, -3 ]
+        , test "round trip" <|
+            \_ -> List.map (A.fromIntAnswer >> A.toIntAnswer) A.valuesOfAnswer |> Expect.equal A.valuesOfAnswer
+        , test "unrecognised number" <|
+            \_ -> A.toIntAnswer 7 |> Expect.equal A.AnswerUnspecified
+        , test "sort" <|
+            \_ -> List.sortWith A.compareAnswer [ A.Yes, A.AnswerUnspecified, A.No ] |> Expect.equal [ A.No, A.AnswerUnspecified, A.Yes ]
+        , test "dict key" <|
+            \_ ->
+                Dict.fromList [ ( A.fromIntAnswer A.No, "no" ) ]
+                    |> Dict.get (A.fromIntAnswer A.No)
+                    |> Expect.equal (Just "no")
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.answers;
+		enum Answer {
+			option allow_alias = true;
+			ANSWER_UNSPECIFIED = 0;
+			YES = 1;
+			ALIAS = 1;
+			NO = -3;
+		}`)
+}
+
 func TestPrefixAndSuffixCollision(t *testing.T) {
 	// If we mix prefixes and suffixes from functions we can potentially get a collision
 	testModule(t, `
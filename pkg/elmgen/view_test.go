@@ -0,0 +1,67 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package elmgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView(t *testing.T) {
+	testElmFiles = map[string]string{"Test/ViewUsageTests.elm": `
+module Test.ViewUsageTests exposing (suite)
+
+import Bytes.Encode as BE
+import Test exposing (Test, describe, test)
+import Test.Html.Query as Query
+import Test.Html.Selector as Selector
+import Test.View as T
+import Test.ViewView as V
+
+
+suite : Test
+suite =
+    let
+        sample =
+            { photo = BE.encode (BE.unsignedInt8 255)
+            , colour = T.Red
+            , owner = Just (T.Sample_Person "sam")
+            }
+
+        has str =
+            test str <|
+                \_ -> Query.fromHtml (V.viewSample sample) |> Query.has [ Selector.text str ]
+    in
+    describe "views"
+        [ has "test.view.Sample"
+        , has "photo (1): "
+        , has "1 bytes: ff"
+        , has "RED = 1"
+        , has "person (3): "
+        , test "not set" <|
+            \_ -> Query.fromHtml (V.viewSample T.emptySample) |> Query.has [ Selector.text "not set" ]
+        ]
+`}
+	defer func() { testElmFiles = nil }()
+
+	testModule(t, `
+		syntax = "proto3";
+		package test.view;
+		enum Colour { COLOUR_UNSPECIFIED = 0; RED = 1; }
+		message Sample {
+			bytes photo = 1;
+			Colour colour = 2;
+			oneof owner { string person = 3; Colour paint = 4; }
+		}`)
+	content := string(testFileContents["Test/ViewView.elm"])
+	assert.Contains(t, content, "viewSample : Test.View.Sample -> Html msg")
+	assert.Contains(t, content, "viewSample_Owner : Test.View.Sample_Owner -> Html msg")
+	assert.Contains(t, content, "viewColour : Test.View.Colour -> Html msg")
+	assert.Contains(t, content, `Protobuf.ElmerView.oneof "owner" viewSample_Owner v.owner`)
+}
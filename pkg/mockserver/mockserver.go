@@ -0,0 +1,187 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package mockserver
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/feral-dot-io/protoc-gen-elmer/pkg/protorand"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// How responses are filled when there's no fixture
+type Fill int
+
+const (
+	FillEmpty  Fill = iota // Zero values i.e., an empty message
+	FillRandom             // Random values from a seed
+)
+
+// Parses a Fill from its name: "empty" or "random"
+func ParseFill(name string) (Fill, error) {
+	switch name {
+	case "empty":
+		return FillEmpty, nil
+	case "random":
+		return FillRandom, nil
+	}
+	return 0, fmt.Errorf("unknown fill %q, expected empty or random", name)
+}
+
+// A Twirp server that replies to every unary method found in a set of descriptors. Requests are decoded (to validate them) and discarded
+type Server struct {
+	fill     Fill
+	fixtures string // Directory of `<package.Service>/<Method>.textproto` responses. Optional
+
+	methods map[string]protoreflect.MethodDescriptor // By route e.g., "pkg.Service/Method"
+	rand    *rand.Rand
+	mu      sync.Mutex // Guards rand
+}
+
+// Creates a server for all unary methods of all services in files. Streaming methods are skipped. Fixtures is a directory of `<package.Service>/<Method>.textproto` responses that take precedence over the fill. Leave empty to only fill
+func New(files *protoregistry.Files, fill Fill, fixtures string, seed int64) *Server {
+	s := &Server{
+		fill:     fill,
+		fixtures: fixtures,
+		methods:  make(map[string]protoreflect.MethodDescriptor),
+		rand:     rand.New(rand.NewSource(seed))}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			sd := services.Get(i)
+			methods := sd.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				md := methods.Get(j)
+				if md.IsStreamingClient() || md.IsStreamingServer() {
+					continue
+				}
+				s.methods[route(md)] = md
+			}
+		}
+		return true
+	})
+	return s
+}
+
+// Returns the routes (e.g., "pkg.Service/Method") being served
+func (s *Server) Routes() []string {
+	var routes []string
+	for r := range s.methods {
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+func route(md protoreflect.MethodDescriptor) string {
+	return string(md.Parent().FullName()) + "/" + string(md.Name())
+}
+
+// Serves Twirp requests. Any path prefix (e.g., "/twirp") is accepted
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, twirp.NewError(twirp.BadRoute, "unsupported method "+r.Method))
+		return
+	}
+	// Last two segments make up the route
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 2 {
+		writeError(w, twirp.NewError(twirp.BadRoute, "no route for "+r.URL.Path))
+		return
+	}
+	md := s.methods[strings.Join(parts[len(parts)-2:], "/")]
+	if md == nil {
+		writeError(w, twirp.NewError(twirp.BadRoute, "no route for "+r.URL.Path))
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "application/protobuf" && contentType != "application/json" {
+		writeError(w, twirp.NewError(twirp.BadRoute, "unsupported Content-Type "+contentType))
+		return
+	}
+
+	// Decode request
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, twirp.WrapError(twirp.NewError(twirp.Internal, "failed to read request"), err))
+		return
+	}
+	req := dynamicpb.NewMessage(md.Input())
+	if contentType == "application/json" {
+		err = protojson.Unmarshal(body, req)
+	} else {
+		err = proto.Unmarshal(body, req)
+	}
+	if err != nil {
+		writeError(w, twirp.NewError(twirp.Malformed, "failed to decode request: "+err.Error()))
+		return
+	}
+
+	// Encode response
+	resp, err := s.Response(md)
+	if err != nil {
+		writeError(w, twirp.WrapError(twirp.NewError(twirp.Internal, err.Error()), err))
+		return
+	}
+	if contentType == "application/json" {
+		body, err = protojson.Marshal(resp)
+	} else {
+		body, err = proto.Marshal(resp)
+	}
+	if err != nil {
+		writeError(w, twirp.WrapError(twirp.NewError(twirp.Internal, "failed to encode response"), err))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("mockserver: writing response: %s", err)
+	}
+}
+
+// Builds a response for a method. Fixtures take precedence over the server's fill
+func (s *Server) Response(md protoreflect.MethodDescriptor) (proto.Message, error) {
+	resp := dynamicpb.NewMessage(md.Output())
+	if s.fixtures != "" {
+		path := filepath.Join(s.fixtures, string(md.Parent().FullName()), string(md.Name())+".textproto")
+		b, err := os.ReadFile(path)
+		if err == nil {
+			if err := prototext.Unmarshal(b, resp); err != nil {
+				return nil, fmt.Errorf("fixture %s: %w", path, err)
+			}
+			return resp, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if s.fill == FillRandom {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		protorand.Fill(s.rand, resp, protorand.DefaultOptions)
+	}
+	return resp, nil
+}
+
+func writeError(w http.ResponseWriter, twerr twirp.Error) {
+	if err := twirp.WriteError(w, twerr); err != nil {
+		log.Printf("mockserver: writing error: %s", err)
+	}
+}
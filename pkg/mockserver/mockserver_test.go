@@ -0,0 +1,147 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package mockserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func testFiles(t *testing.T) *protoregistry.Files {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Type:     typ.Enum(),
+			Label:    label.Enum()}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("hats.proto"),
+		Package: proto.String("test.hats"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Size"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("inches", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, "")}},
+			{Name: proto.String("Hat"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("inches", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+				field("color", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				field("sizes", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".test.hats.Size")}}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Haberdasher"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("MakeHat"),
+				InputType:  proto.String(".test.hats.Size"),
+				OutputType: proto.String(".test.hats.Hat")}, {
+				Name:            proto.String("Watch"),
+				InputType:       proto.String(".test.hats.Size"),
+				OutputType:      proto.String(".test.hats.Hat"),
+				ServerStreaming: proto.Bool(true)}}}}}
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{fdp}})
+	assert.NoError(t, err)
+	return files
+}
+
+func testCall(t *testing.T, s *Server, path, contentType string, body []byte) (*httptest.ResponseRecorder, *dynamicpb.Message) {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	desc, err := testFiles(t).FindDescriptorByName("test.hats.Hat")
+	assert.NoError(t, err)
+	hat := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+	if w.Code == http.StatusOK {
+		if contentType == "application/json" {
+			assert.NoError(t, protojson.Unmarshal(w.Body.Bytes(), hat))
+		} else {
+			assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), hat))
+		}
+	}
+	return w, hat
+}
+
+func TestRoutes(t *testing.T) {
+	s := New(testFiles(t), FillEmpty, "", 0)
+	assert.Equal(t, []string{"test.hats.Haberdasher/MakeHat"}, s.Routes())
+
+	// Any prefix
+	for _, path := range []string{"/test.hats.Haberdasher/MakeHat", "/twirp/test.hats.Haberdasher/MakeHat"} {
+		w, _ := testCall(t, s, path, "application/protobuf", nil)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, "application/protobuf", w.Header().Get("Content-Type"))
+	}
+	// Bad routes
+	for _, path := range []string{"/", "/test.hats.Haberdasher/Watch", "/test.hats.Haberdasher/Missing"} {
+		w, _ := testCall(t, s, path, "application/protobuf", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+	w, _ := testCall(t, s, "/test.hats.Haberdasher/MakeHat", "text/plain", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = testCall(t, s, "/test.hats.Haberdasher/MakeHat", "application/protobuf", []byte{0xff})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFill(t *testing.T) {
+	// Empty
+	s := New(testFiles(t), FillEmpty, "", 0)
+	_, hat := testCall(t, s, "/test.hats.Haberdasher/MakeHat", "application/json", []byte(`{"inches": 12}`))
+	color := hat.Descriptor().Fields().ByName("color")
+	assert.Equal(t, "", hat.Get(color).String())
+
+	// Random is repeatable given a seed
+	var colors []string
+	for i := 0; i < 2; i++ {
+		s := New(testFiles(t), FillRandom, "", 42)
+		_, hat := testCall(t, s, "/test.hats.Haberdasher/MakeHat", "application/json", []byte(`{}`))
+		colors = append(colors, hat.Get(hat.Descriptor().Fields().ByName("color")).String())
+	}
+	assert.NotEqual(t, "", colors[0])
+	assert.Equal(t, colors[0], colors[1])
+}
+
+func TestFixtures(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "test.hats.Haberdasher"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "test.hats.Haberdasher", "MakeHat.textproto"),
+		[]byte(`inches: 7 color: "red" sizes { inches: 1 }`), 0644))
+
+	s := New(testFiles(t), FillRandom, dir, 0)
+	w, hat := testCall(t, s, "/test.hats.Haberdasher/MakeHat", "application/protobuf", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	fields := hat.Descriptor().Fields()
+	assert.Equal(t, int64(7), hat.Get(fields.ByName("inches")).Int())
+	assert.Equal(t, "red", hat.Get(fields.ByName("color")).String())
+	assert.Equal(t, 1, hat.Get(fields.ByName("sizes")).List().Len())
+
+	// Bad fixture
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "test.hats.Haberdasher", "MakeHat.textproto"),
+		[]byte(`nope: 1`), 0644))
+	w, _ = testCall(t, s, "/test.hats.Haberdasher/MakeHat", "application/protobuf", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,171 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+
+// Fills Protobuf messages with random values. Used to create responses (mock server) and test corpora (conformance)
+package protorand
+
+import (
+	"math"
+	"math/rand"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Options for filling messages
+type Options struct {
+	MaxDepth int  // Nested messages beyond this are left unset
+	MaxLen   int  // Most elements in a repeated field or map
+	NonZero  bool // Avoids default values so that every set field appears on the wire
+}
+
+// Small messages that are easy to read
+var DefaultOptions = Options{MaxDepth: 3, MaxLen: 3}
+
+var words = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel"}
+
+// Sets every field of a message to a random value. One field of each oneof is chosen (or none)
+func Fill(r *rand.Rand, m protoreflect.Message, opts Options) {
+	fill(r, m, opts, 0)
+}
+
+func fill(r *rand.Rand, m protoreflect.Message, opts Options, depth int) {
+	md := m.Descriptor()
+	// Well-known types with constraints on their values
+	switch md.FullName() {
+	case "google.protobuf.Any": // Needs a resolvable type URL
+		return
+	case "google.protobuf.Timestamp", "google.protobuf.Duration": // Nanos must match the seconds' sign
+		m.Set(md.Fields().ByName("seconds"), protoreflect.ValueOfInt64(1+r.Int63n(2000000000)))
+		return
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.ContainingOneof() != nil {
+			continue
+		}
+		fillField(r, m, fd, opts, depth)
+	}
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		choices := oneofs.Get(i).Fields()
+		// Includes an extra choice for not setting a field
+		if n := r.Intn(choices.Len() + 1); n < choices.Len() {
+			fillField(r, m, choices.Get(n), opts, depth)
+		}
+	}
+}
+
+func fillField(r *rand.Rand, m protoreflect.Message, fd protoreflect.FieldDescriptor, opts Options, depth int) {
+	isMsg := fd.Message() != nil && !fd.IsMap()
+	if isMsg && depth >= opts.MaxDepth {
+		return
+	}
+	switch {
+	case fd.IsMap():
+		mp := m.Mutable(fd).Map()
+		for n := r.Intn(opts.MaxLen + 1); n > 0; n-- {
+			key := Scalar(r, fd.MapKey(), opts)
+			val := fd.MapValue()
+			if val.Message() != nil {
+				if depth >= opts.MaxDepth {
+					return
+				}
+				v := mp.NewValue()
+				fill(r, v.Message(), opts, depth+1)
+				mp.Set(key.MapKey(), v)
+			} else {
+				mp.Set(key.MapKey(), Scalar(r, val, opts))
+			}
+		}
+
+	case fd.IsList():
+		list := m.Mutable(fd).List()
+		for n := r.Intn(opts.MaxLen + 1); n > 0; n-- {
+			if isMsg {
+				v := list.NewElement()
+				fill(r, v.Message(), opts, depth+1)
+				list.Append(v)
+			} else {
+				list.Append(Scalar(r, fd, opts))
+			}
+		}
+
+	case isMsg:
+		fill(r, m.Mutable(fd).Message(), opts, depth+1)
+
+	default:
+		m.Set(fd, Scalar(r, fd, opts))
+	}
+}
+
+// Creates a random value for a non-message field. Numbers are usually small so they're easy to read. Floats are exact in both 32 and 64 bits
+func Scalar(r *rand.Rand, fd protoreflect.FieldDescriptor, opts Options) protoreflect.Value {
+	// Signed, mostly small
+	intn := func(bits uint) int64 {
+		for {
+			v := int64(r.Intn(201) - 100)
+			if r.Intn(4) == 0 { // Anywhere in range
+				v = r.Int63n(1<<bits) - 1<<(bits-1)
+			}
+			if v != 0 || !opts.NonZero {
+				return v
+			}
+		}
+	}
+	// Unsigned 32-bit, mostly small
+	uintn := func() int64 {
+		v := intn(32)
+		if v < 0 {
+			v += math.MaxUint32 + 1
+		}
+		return v
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(opts.NonZero || r.Intn(2) == 1)
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(intn(32)))
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(intn(32))
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(uintn()))
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(uintn()))
+
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(intn(16)) / 4)
+
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(intn(16)) / 4)
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(words[r.Intn(len(words))])
+
+	case protoreflect.BytesKind:
+		b := make([]byte, r.Intn(8))
+		if opts.NonZero {
+			b = append(b, 0)
+		}
+		r.Read(b)
+		return protoreflect.ValueOfBytes(b)
+
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		v := values.Get(r.Intn(values.Len())).Number()
+		for tries := 0; v == 0 && opts.NonZero && tries < values.Len(); tries++ {
+			v = values.Get(r.Intn(values.Len())).Number()
+		}
+		return protoreflect.ValueOfEnum(v)
+	}
+	panic("protorand: unsupported kind: " + fd.Kind().String())
+}
@@ -0,0 +1,54 @@
+// This file is part of protoc-gen-elmer.
+//
+// Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+// Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with Protoc-gen-elmer. If not, see <https://www.gnu.org/licenses/>.
+package protorand
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestFill(t *testing.T) {
+	var fields []*typepb.Field
+	for i := 0; i < 2; i++ {
+		field := new(typepb.Field)
+		Fill(rand.New(rand.NewSource(42)), field.ProtoReflect(), DefaultOptions)
+		fields = append(fields, field)
+	}
+	assert.True(t, proto.Equal(fields[0], fields[1]), "same seed")
+}
+
+func TestFillNonZero(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		field := new(typepb.Field)
+		Fill(r, field.ProtoReflect(), Options{MaxDepth: 2, MaxLen: 2, NonZero: true})
+		// Every scalar is on the wire
+		fds := field.ProtoReflect().Descriptor().Fields()
+		for j := 0; j < fds.Len(); j++ {
+			if fd := fds.Get(j); !fd.IsList() {
+				assert.True(t, field.ProtoReflect().Has(fd), fd.Name())
+			}
+		}
+		// Floats are exact i.e., quarter steps
+		fv := new(wrapperspb.FloatValue)
+		Fill(r, fv.ProtoReflect(), Options{NonZero: true})
+		assert.NotZero(t, fv.Value)
+		assert.Equal(t, float32(int(fv.Value*4)), fv.Value*4)
+	}
+
+	// Timestamps are valid
+	ts := new(timestamppb.Timestamp)
+	Fill(r, ts.ProtoReflect(), DefaultOptions)
+	assert.NoError(t, ts.CheckValid())
+}
@@ -0,0 +1,93 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+
+module Protobuf.ElmerSimulated exposing (simulate, responseBody, decodeRequestBody)
+
+{-| An adapter from `protoc-gen-elmer-twirp` effects (`effects=t`) to [elm-program-test](https://package.elm-lang.org/packages/avh4/elm-program-test/latest/)'s `SimulatedEffect`. This module is not part of the package as it would force elm-program-test on everyone. Copy it into your tests' source directory.
+
+elm-program-test simulates HTTP with string bodies so Protobuf messages are base64 encoded in both directions. For example:
+
+    ProgramTest.simulateHttpOk "POST"
+        "/twirp/example.Haberdasher/MakeHat"
+        (responseBody (Example.encodeHat { inches = 12, color = "red" }))
+
+
+# Simulating
+
+@docs simulate, responseBody, decodeRequestBody
+
+-}
+
+import Bytes exposing (Bytes)
+import Http
+import ProgramTest exposing (SimulatedEffect)
+import Protobuf.Decode as PD
+import Protobuf.Elmer exposing (emptyBytes, fromBase64, toBase64)
+import Protobuf.ElmerEffect exposing (Rpc)
+import Protobuf.Encode as PE
+import SimulatedEffect.Http as SimulatedHttp
+
+
+{-| Simulates an RPC call against a Twirp server at the given URL prefix e.g., `simulate api (describe effect)`.
+-}
+simulate : String -> Rpc msg -> SimulatedEffect msg
+simulate api rpc =
+    SimulatedHttp.request
+        { method = "POST"
+        , headers = []
+        , url = api ++ "/" ++ rpc.service ++ "/" ++ rpc.method
+        , body = SimulatedHttp.stringBody "application/protobuf" (toBase64 rpc.request)
+        , expect = SimulatedHttp.expectStringResponse fromOk (toBytesResponse >> rpc.respond >> Ok)
+        , timeout = Nothing
+        , tracker = Nothing
+        }
+
+
+{-| Encodes a canned response for `ProgramTest.simulateHttpOk` and friends.
+-}
+responseBody : PE.Encoder -> String
+responseBody =
+    PE.encode >> toBase64
+
+
+{-| Decodes a request body for checking with `ProgramTest.expectHttpRequest`.
+-}
+decodeRequestBody : PD.Decoder a -> String -> Maybe a
+decodeRequestBody decoder =
+    fromBase64 >> Maybe.andThen (PD.decode decoder)
+
+
+toBytesResponse : Http.Response String -> Http.Response Bytes
+toBytesResponse response =
+    case response of
+        Http.BadUrl_ url ->
+            Http.BadUrl_ url
+
+        Http.Timeout_ ->
+            Http.Timeout_
+
+        Http.NetworkError_ ->
+            Http.NetworkError_
+
+        Http.BadStatus_ metadata body ->
+            Http.BadStatus_ metadata (fromBase64 body |> Maybe.withDefault emptyBytes)
+
+        Http.GoodStatus_ metadata body ->
+            Http.GoodStatus_ metadata (fromBase64 body |> Maybe.withDefault emptyBytes)
+
+
+fromOk : Result Never a -> a
+fromOk result =
+    case result of
+        Ok a ->
+            a
+
+        Err err ->
+            never err
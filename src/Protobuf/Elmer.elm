@@ -12,6 +12,15 @@ module Protobuf.Elmer exposing
     , emptyAny, emptyApi, emptyBoolValue, emptyBytes, emptyBytesValue, emptyDoubleValue, emptyDuration, emptyEmpty, emptyEnum, emptyEnumValue, emptyField, emptyFieldMask, emptyField_Cardinality, emptyField_Kind, emptyFloatValue, emptyInt32Value, emptyInt64Value, emptyListValue, emptyMethod, emptyMixin, emptyNullValue, emptyOption, emptySourceContext, emptyStringValue, emptyStruct, emptySyntax, emptyTimestamp, emptyUInt32Value, emptyUInt64Value, emptyValue, emptyXType
     , decodeBoolValue, decodeBytesValue, decodeDoubleValue, decodeFloatValue, decodeInt32Value, decodeInt64Value, decodeStringValue, decodeTimestamp, decodeUInt32Value, decodeUInt64Value, decodeValue
     , encodeAny, encodeBoolValue, encodeBytesValue, encodeDoubleValue, encodeFloatValue, encodeInt32Value, encodeInt64Value, encodeStringValue, encodeTimestamp, encodeUInt32Value, encodeUInt64Value, encodeValue
+    , toBase64, fromBase64
+    , assocToDict, dictToAssoc, boolToKey, keyToBool
+    , Strict, StrictField, decodeStrict, strictMessage, strictScalar, strictEnum, strictNested
+    , Lens, Prism, Optional, composeLens, composePrism, composeOptional, modify, modifyOptional
+    , equalBytes, equalList, equalDict, equalAssoc, equalMaybe, equalEncoded
+    , mergeSingular, mergeMaybe, mergeDict, mergeAssoc
+    , diffField, diffNested, diffOneof, patchField, patchNested, patchOneof
+    , TextValue, Text, printText, parseText, textBool, textInt, textFloat, textString, textBytes, textEnum, textMessage, textEntry, textSingular, textRepeated, textOptional, parseTextField, textUnknown
+    , textBoolValue, textBytesValue, textDoubleValue, textFloatValue, textInt32Value, textInt64Value, textStringValue, textUInt32Value, textUInt64Value, textTimestamp, textDuration, textEmpty
     )
 
 {-| Helper types and functions for `protoc-gen-elmer` codegen. This module should not be used directly.
@@ -38,11 +47,68 @@ See the project on how this may be used: <https://github.com/feral-dot-io/protoc
 
 @docs encodeAny, encodeBoolValue, encodeBytesValue, encodeDoubleValue, encodeFloatValue, encodeInt32Value, encodeInt64Value, encodeStringValue, encodeTimestamp, encodeUInt32Value, encodeUInt64Value, encodeValue
 
+
+# Base64
+
+@docs toBase64, fromBase64
+
+
+# Maps
+
+Elm's `Dict` needs `comparable` keys. Maps with other keys (e.g., `map<bool, V>`) are association lists sorted by key without duplicates. These convert them to and from a `Dict` with comparable keys.
+
+@docs assocToDict, dictToAssoc, boolToKey, keyToBool
+
+
+# Strict decoding
+
+Decoders skip unknown fields and collapse unknown enum numbers into the default. Strict decoders (generated with `strict=t`) check the payload against a description of the message first and reject it instead.
+
+@docs Strict, StrictField, decodeStrict, strictMessage, strictScalar, strictEnum, strictNested
+
+
+# Lenses
+
+Codecs generated with `setters=t` have a `Lens` per record field e.g., `lensFooBar` and a `Prism` per oneof variant e.g., `prismFoo_Baz`. Compose them to reach into nested messages.
+
+@docs Lens, Prism, Optional, composeLens, composePrism, composeOptional, modify, modifyOptional
+
+
+# Equality
+
+Elm's `==` crashes on `Bytes`. Generated `equalFoo` functions use these instead.
+
+@docs equalBytes, equalList, equalDict, equalAssoc, equalMaybe, equalEncoded
+
+
+# Merging
+
+Generated `mergeFoo` functions follow Protobuf's merge: singular fields are overwritten when set, lists are concatenated, maps are merged by key and oneofs are replaced. Messages are merged recursively.
+
+@docs mergeSingular, mergeMaybe, mergeDict, mergeAssoc
+
+
+# Field masks
+
+Generated `diffFoo` functions list the `FieldMask` paths that changed between two records. Nested messages are recursed into (e.g., `"sub.name"`), repeated, map and oneof fields are whole values. Generated `patchFoo paths new old` copies those paths from `new` onto `old`.
+
+@docs diffField, diffNested, diffOneof, patchField, patchNested, patchOneof
+
+
+# Text format
+
+Generated `textFoo` codecs print and parse Protobuf's text format (textproto) using the original field names. Well-known wrappers and `Timestamp` are supported.
+
+@docs TextValue, Text, printText, parseText, textBool, textInt, textFloat, textString, textBytes, textEnum, textMessage, textEntry, textSingular, textRepeated, textOptional, parseTextField, textUnknown
+@docs textBoolValue, textBytesValue, textDoubleValue, textFloatValue, textInt32Value, textInt64Value, textStringValue, textUInt32Value, textUInt64Value, textTimestamp, textDuration, textEmpty
+
 -}
 
+import Bitwise
 import Bytes exposing (Bytes)
+import Bytes.Decode as BD
 import Bytes.Encode as BE
-import Dict
+import Dict exposing (Dict)
 import Google.Protobuf as GP
 import Protobuf.Decode as PD
 import Protobuf.Encode as PE
@@ -445,3 +511,1403 @@ emptyXType =
 emptyValue : GP.Value
 emptyValue =
     GP.Value (GP.ValueKind Nothing)
+
+
+
+-- Maps
+
+
+{-| Converts an association list to a Dict. Later duplicate keys win.
+-}
+assocToDict : (k -> comparable) -> List ( k, v ) -> Dict comparable v
+assocToDict toKey =
+    List.foldl (\( k, v ) -> Dict.insert (toKey k) v) Dict.empty
+
+
+{-| Converts a Dict back to an association list, sorted by key.
+-}
+dictToAssoc : (comparable -> k) -> Dict comparable v -> List ( k, v )
+dictToAssoc fromKey =
+    Dict.foldr (\k v acc -> ( fromKey k, v ) :: acc) []
+
+
+{-| Comparable key for a bool. False sorts first.
+-}
+boolToKey : Bool -> Int
+boolToKey b =
+    if b then
+        1
+
+    else
+        0
+
+
+{-| Bool from a key made by `boolToKey`.
+-}
+keyToBool : Int -> Bool
+keyToBool k =
+    k /= 0
+
+
+
+-- Equality
+
+
+{-| Compares bytes by content.
+-}
+equalBytes : Bytes -> Bytes -> Bool
+equalBytes a b =
+    Bytes.width a == Bytes.width b && toBase64 a == toBase64 b
+
+
+{-| -}
+equalList : (a -> a -> Bool) -> List a -> List a -> Bool
+equalList eq a b =
+    List.length a == List.length b && List.all identity (List.map2 eq a b)
+
+
+{-| -}
+equalDict : (v -> v -> Bool) -> Dict comparable v -> Dict comparable v -> Bool
+equalDict eq a b =
+    equalAssoc eq (Dict.toList a) (Dict.toList b)
+
+
+{-| Compares association lists, keys use `==`.
+-}
+equalAssoc : (v -> v -> Bool) -> List ( k, v ) -> List ( k, v ) -> Bool
+equalAssoc eq =
+    equalList (\( k1, v1 ) ( k2, v2 ) -> k1 == k2 && eq v1 v2)
+
+
+{-| -}
+equalMaybe : (a -> a -> Bool) -> Maybe a -> Maybe a -> Bool
+equalMaybe eq a b =
+    case ( a, b ) of
+        ( Just x, Just y ) ->
+            eq x y
+
+        ( Nothing, Nothing ) ->
+            True
+
+        _ ->
+            False
+
+
+{-| Compares values by their encoding. Used for well-known types that may hold bytes e.g., `Any`.
+-}
+equalEncoded : (a -> PE.Encoder) -> a -> a -> Bool
+equalEncoded encoder a b =
+    equalBytes (PE.encode (encoder a)) (PE.encode (encoder b))
+
+
+
+-- Merging
+
+
+{-| Takes the new value unless it's the zero value (i.e., not on the wire).
+-}
+mergeSingular : (a -> a -> Bool) -> a -> a -> a -> a
+mergeSingular eq zero old new =
+    if eq new zero then
+        old
+
+    else
+        new
+
+
+{-| Takes the new value if set. Used by optional fields and oneofs.
+-}
+mergeMaybe : Maybe a -> Maybe a -> Maybe a
+mergeMaybe old new =
+    case new of
+        Just _ ->
+            new
+
+        Nothing ->
+            old
+
+
+{-| New keys win.
+-}
+mergeDict : Dict comparable v -> Dict comparable v -> Dict comparable v
+mergeDict old new =
+    Dict.union new old
+
+
+{-| New keys win. See `assocToDict`.
+-}
+mergeAssoc : (k -> comparable) -> (comparable -> k) -> List ( k, v ) -> List ( k, v ) -> List ( k, v )
+mergeAssoc toKey fromKey old new =
+    dictToAssoc fromKey (mergeDict (assocToDict toKey old) (assocToDict toKey new))
+
+
+
+-- Field masks
+
+
+{-| The field's path if it changed.
+-}
+diffField : String -> (a -> a -> Bool) -> a -> a -> List String
+diffField name eq old new =
+    if eq old new then
+        []
+
+    else
+        [ name ]
+
+
+{-| Paths that changed within a nested message, prefixed with its name.
+-}
+diffNested : String -> (a -> a -> List String) -> a -> a -> List String
+diffNested name diff old new =
+    List.map (\path -> name ++ "." ++ path) (diff old new)
+
+
+{-| The paths of the variants set before and after, if they differ.
+-}
+diffOneof : Bool -> Maybe String -> Maybe String -> List String
+diffOneof same old new =
+    if same then
+        []
+
+    else if old == new then
+        List.filterMap identity [ new ]
+
+    else
+        List.filterMap identity [ old, new ]
+
+
+{-| Takes the new value if its path is in the mask.
+-}
+patchField : String -> List String -> a -> a -> a
+patchField name paths new old =
+    if List.member name paths then
+        new
+
+    else
+        old
+
+
+{-| Takes the new message if its path is in the mask, otherwise patches it with paths below it.
+-}
+patchNested : String -> (List String -> a -> a -> a) -> List String -> a -> a -> a
+patchNested name patch paths new old =
+    if List.member name paths then
+        new
+
+    else
+        case List.filter (String.startsWith (name ++ ".")) paths of
+            [] ->
+                old
+
+            subPaths ->
+                patch (List.map (String.dropLeft (String.length name + 1)) subPaths) new old
+
+
+{-| Takes the new oneof if any of its variants' paths are in the mask.
+-}
+patchOneof : List String -> List String -> a -> a -> a
+patchOneof names paths new old =
+    if List.any (\name -> List.member name paths) names then
+        new
+
+    else
+        old
+
+
+
+-- Lenses
+
+
+{-| Gets and sets a part of a structure e.g., a record field.
+-}
+type alias Lens a b =
+    { get : a -> b
+    , set : b -> a -> a
+    }
+
+
+{-| A part of a structure that may not be there e.g., a oneof variant.
+-}
+type alias Prism a b =
+    { getOption : a -> Maybe b
+    , reverseGet : b -> a
+    }
+
+
+{-| Gets and sets a part of a structure that may not be there.
+-}
+type alias Optional a b =
+    { getOption : a -> Maybe b
+    , set : b -> a -> a
+    }
+
+
+{-| Focuses a lens on a part of its part e.g., `composeLens lensFooBar lensBarBaz`.
+-}
+composeLens : Lens a b -> Lens b c -> Lens a c
+composeLens outer inner =
+    Lens (outer.get >> inner.get)
+        (\c a -> outer.set (inner.set c (outer.get a)) a)
+
+
+{-| Focuses a lens on a oneof variant e.g., `composePrism lensFooChoice prismFoo_Baz`.
+-}
+composePrism : Lens a b -> Prism b c -> Optional a c
+composePrism outer inner =
+    Optional (outer.get >> inner.getOption)
+        (\c -> outer.set (inner.reverseGet c))
+
+
+{-| Focuses an optional on a part of its part.
+-}
+composeOptional : Optional a b -> Lens b c -> Optional a c
+composeOptional outer inner =
+    Optional (outer.getOption >> Maybe.map inner.get)
+        (\c a ->
+            case outer.getOption a of
+                Just b ->
+                    outer.set (inner.set c b) a
+
+                Nothing ->
+                    a
+        )
+
+
+{-| Updates the part a lens focuses on.
+-}
+modify : Lens a b -> (b -> b) -> a -> a
+modify lens f a =
+    lens.set (f (lens.get a)) a
+
+
+{-| Updates the part an optional focuses on, if it's there.
+-}
+modifyOptional : Optional a b -> (b -> b) -> a -> a
+modifyOptional optional f a =
+    case optional.getOption a of
+        Just b ->
+            optional.set (f b) a
+
+        Nothing ->
+            a
+
+
+
+-- Strict decoding
+
+
+{-| The fields a message is allowed to have.
+-}
+type Strict
+    = Strict (Dict Int StrictField)
+
+
+{-| What a field is allowed to hold.
+-}
+type StrictField
+    = StrictScalar
+    | StrictEnum (List Int)
+    | StrictNested (() -> Strict)
+
+
+{-| Describes a message by its field numbers.
+-}
+strictMessage : List ( Int, StrictField ) -> Strict
+strictMessage =
+    Dict.fromList >> Strict
+
+
+{-| A field that is never rejected.
+-}
+strictScalar : StrictField
+strictScalar =
+    StrictScalar
+
+
+{-| An enum field (including packed lists) that only accepts the given numbers.
+-}
+strictEnum : List Int -> StrictField
+strictEnum =
+    StrictEnum
+
+
+{-| A message field. Lazy to allow recursive messages.
+-}
+strictNested : (() -> Strict) -> StrictField
+strictNested =
+    StrictNested
+
+
+{-| Decodes a payload only if every field is known and every enum number is defined. Rejected payloads decode to `Nothing`, a decoder can't otherwise fail on demand.
+-}
+decodeStrict : Strict -> PD.Decoder a -> PD.Decoder (Maybe a)
+decodeStrict strict decoder =
+    PD.map
+        (\bytes ->
+            if strictValid (strictFields strict) bytes then
+                PD.decode decoder bytes
+
+            else
+                Nothing
+        )
+        PD.bytes
+
+
+strictValid : (Int -> BD.Decoder Bool) -> Bytes -> Bool
+strictValid check bytes =
+    BD.decode (check (Bytes.width bytes)) bytes == Just True
+
+
+strictFields : Strict -> Int -> BD.Decoder Bool
+strictFields (Strict fields) width =
+    BD.loop width
+        (\remaining ->
+            if remaining <= 0 then
+                BD.succeed (BD.Done (remaining == 0))
+
+            else
+                strictVarint
+                    |> BD.andThen
+                        (\( tag, tagWidth ) ->
+                            case Dict.get (tag // 8) fields of
+                                Just field ->
+                                    strictField field (modBy 8 tag)
+                                        |> BD.map
+                                            (\( ok, fieldWidth ) ->
+                                                if ok then
+                                                    BD.Loop (remaining - tagWidth - fieldWidth)
+
+                                                else
+                                                    BD.Done False
+                                            )
+
+                                Nothing ->
+                                    BD.succeed (BD.Done False)
+                        )
+        )
+
+
+{-| Checks a field's value given its wire type. Returns the bytes consumed.
+-}
+strictField : StrictField -> Int -> BD.Decoder ( Bool, Int )
+strictField field wireType =
+    case wireType of
+        0 ->
+            BD.map (\( v, w ) -> ( strictEnumValue field v, w )) strictVarint
+
+        1 ->
+            BD.map (\_ -> ( True, 8 )) (BD.bytes 8)
+
+        2 ->
+            strictVarint
+                |> BD.andThen
+                    (\( len, w ) ->
+                        BD.map (\b -> ( strictDelimited field b, w + len )) (BD.bytes len)
+                    )
+
+        5 ->
+            BD.map (\_ -> ( True, 4 )) (BD.bytes 4)
+
+        -- Groups
+        _ ->
+            BD.succeed ( False, 0 )
+
+
+strictEnumValue : StrictField -> Int -> Bool
+strictEnumValue field v =
+    case field of
+        StrictEnum values ->
+            -- Negative enums are sign extended to 64 bits, keep the low 32
+            List.member (Bitwise.or 0 v) values
+
+        _ ->
+            True
+
+
+strictDelimited : StrictField -> Bytes -> Bool
+strictDelimited field bytes =
+    case field of
+        StrictScalar ->
+            True
+
+        StrictEnum _ ->
+            -- Packed
+            strictValid
+                (\width ->
+                    BD.loop width
+                        (\remaining ->
+                            if remaining <= 0 then
+                                BD.succeed (BD.Done (remaining == 0))
+
+                            else
+                                strictVarint
+                                    |> BD.map
+                                        (\( v, w ) ->
+                                            if strictEnumValue field v then
+                                                BD.Loop (remaining - w)
+
+                                            else
+                                                BD.Done False
+                                        )
+                        )
+                )
+                bytes
+
+        StrictNested nested ->
+            strictValid (strictFields (nested ())) bytes
+
+
+{-| Reads a varint returning its value and width. Only the low 35 bits are kept, enough for tags, lengths and int32.
+-}
+strictVarint : BD.Decoder ( Int, Int )
+strictVarint =
+    BD.loop ( 0, 0 )
+        (\( acc, n ) ->
+            BD.map
+                (\b ->
+                    let
+                        next =
+                            if n < 5 then
+                                acc + Bitwise.and b 0x7F * 128 ^ n
+
+                            else
+                                acc
+                    in
+                    if b < 0x80 then
+                        BD.Done ( next, n + 1 )
+
+                    else
+                        BD.Loop ( next, n + 1 )
+                )
+                BD.unsignedInt8
+        )
+
+
+
+-- Text format
+
+
+{-| A value in Protobuf's text format. Scalars are kept as written (e.g., `42`, `RED` or `true`) and quoted strings as bytes.
+-}
+type TextValue
+    = TextScalar String
+    | TextQuoted (List Int)
+    | TextMessage (List ( String, TextValue ))
+
+
+{-| Prints and parses a type in the text format.
+-}
+type alias Text a =
+    { print : a -> TextValue
+    , parse : TextValue -> Result String a
+    }
+
+
+{-| Prints a value in the text format. Messages are printed one field per line.
+-}
+printText : Text a -> a -> String
+printText codec v =
+    textToString (codec.print v)
+
+
+{-| Parses a message in the text format. Comments (`#`), `<>` brackets, list values (`[1, 2]`) and `,` or `;` separators are accepted.
+-}
+parseText : Text a -> String -> Result String a
+parseText codec str =
+    textTokens (String.toList str) []
+        |> Result.andThen (\tokens -> textFields tokens [])
+        |> Result.andThen
+            (\( fields, rest ) ->
+                if List.isEmpty rest then
+                    codec.parse (TextMessage fields)
+
+                else
+                    Err "unexpected closing bracket"
+            )
+
+
+{-| -}
+textBool : Text Bool
+textBool =
+    { print =
+        \v ->
+            if v then
+                TextScalar "true"
+
+            else
+                TextScalar "false"
+    , parse =
+        \value ->
+            case value of
+                TextScalar "true" ->
+                    Ok True
+
+                TextScalar "True" ->
+                    Ok True
+
+                TextScalar "t" ->
+                    Ok True
+
+                TextScalar "1" ->
+                    Ok True
+
+                TextScalar "false" ->
+                    Ok False
+
+                TextScalar "False" ->
+                    Ok False
+
+                TextScalar "f" ->
+                    Ok False
+
+                TextScalar "0" ->
+                    Ok False
+
+                _ ->
+                    Err "expected a bool"
+    }
+
+
+{-| -}
+textInt : Text Int
+textInt =
+    { print = String.fromInt >> TextScalar
+    , parse =
+        \value ->
+            case value of
+                TextScalar s ->
+                    textParseInt s |> Result.fromMaybe ("expected an integer, got " ++ s)
+
+                _ ->
+                    Err "expected an integer"
+    }
+
+
+{-| -}
+textFloat : Text Float
+textFloat =
+    { print =
+        \v ->
+            if isNaN v then
+                TextScalar "nan"
+
+            else if isInfinite v && v > 0 then
+                TextScalar "inf"
+
+            else if isInfinite v then
+                TextScalar "-inf"
+
+            else
+                TextScalar (String.fromFloat v)
+    , parse =
+        \value ->
+            case value of
+                TextScalar s ->
+                    textParseFloat s |> Result.fromMaybe ("expected a float, got " ++ s)
+
+                _ ->
+                    Err "expected a float"
+    }
+
+
+{-| Strings are UTF-8. Anything other than printable ASCII is escaped.
+-}
+textString : Text String
+textString =
+    { print = \v -> TextQuoted (bytesToList (BE.encode (BE.string v)))
+    , parse =
+        \value ->
+            case value of
+                TextQuoted bytes ->
+                    BD.decode (BD.string (List.length bytes)) (listToBytes bytes)
+                        |> Result.fromMaybe "invalid UTF-8 string"
+
+                _ ->
+                    Err "expected a string"
+    }
+
+
+{-| -}
+textBytes : Text Bytes
+textBytes =
+    { print = bytesToList >> TextQuoted
+    , parse =
+        \value ->
+            case value of
+                TextQuoted bytes ->
+                    Ok (listToBytes bytes)
+
+                _ ->
+                    Err "expected bytes"
+    }
+
+
+{-| Prints enum names. Parses names or wire numbers.
+-}
+textEnum : List a -> (a -> String) -> (Int -> a) -> Text a
+textEnum values toName fromNumber =
+    { print = toName >> TextScalar
+    , parse =
+        \value ->
+            case value of
+                TextScalar s ->
+                    case List.filter (\v -> toName v == s) values of
+                        v :: _ ->
+                            Ok v
+
+                        [] ->
+                            textParseInt s
+                                |> Maybe.map fromNumber
+                                |> Result.fromMaybe ("unknown enum value " ++ s)
+
+                _ ->
+                    Err "expected an enum"
+    }
+
+
+{-| A message from its zero value, a printer of its fields and a parser for each field. Fields are parsed in order.
+-}
+textMessage : a -> (a -> List ( String, TextValue )) -> (String -> TextValue -> a -> Result String a) -> Text a
+textMessage zero print parseField =
+    { print = print >> TextMessage
+    , parse =
+        \value ->
+            case value of
+                TextMessage fields ->
+                    List.foldl (\( name, v ) acc -> Result.andThen (parseField name v) acc) (Ok zero) fields
+
+                _ ->
+                    Err "expected a message"
+    }
+
+
+{-| A map entry. Maps are printed as a repeated message with `key` and `value` fields.
+-}
+textEntry : Text k -> Text v -> k -> v -> Text ( k, v )
+textEntry key val keyZero valZero =
+    textMessage ( keyZero, valZero )
+        (\( k, v ) -> [ ( "key", key.print k ), ( "value", val.print v ) ])
+        (\name value ( k, v ) ->
+            case name of
+                "key" ->
+                    Result.map (\x -> ( x, v )) (key.parse value)
+
+                "value" ->
+                    Result.map (Tuple.pair k) (val.parse value)
+
+                _ ->
+                    textUnknown name value ( k, v )
+        )
+
+
+{-| Prints a field unless it's the zero value.
+-}
+textSingular : String -> Text a -> (a -> a -> Bool) -> a -> a -> List ( String, TextValue )
+textSingular name codec eq zero v =
+    if eq zero v then
+        []
+
+    else
+        [ ( name, codec.print v ) ]
+
+
+{-| Prints a field once per value.
+-}
+textRepeated : String -> Text a -> List a -> List ( String, TextValue )
+textRepeated name codec =
+    List.map (\v -> ( name, codec.print v ))
+
+
+{-| Prints a field if present.
+-}
+textOptional : String -> Text a -> Maybe a -> List ( String, TextValue )
+textOptional name codec v =
+    textRepeated name codec (List.filterMap identity [ v ])
+
+
+{-| Parses a field's value and sets it on the message.
+-}
+parseTextField : Text a -> (a -> m -> m) -> TextValue -> m -> Result String m
+parseTextField codec set value m =
+    Result.map (\v -> set v m) (codec.parse value)
+
+
+{-| Rejects a field the message doesn't have.
+-}
+textUnknown : String -> TextValue -> m -> Result String m
+textUnknown name _ _ =
+    Err ("unknown field " ++ name)
+
+
+{-| -}
+textBoolValue : Text BoolValue
+textBoolValue =
+    textWrapper textBool False
+
+
+{-| -}
+textBytesValue : Text BytesValue
+textBytesValue =
+    textWrapper textBytes emptyBytes
+
+
+{-| -}
+textDoubleValue : Text DoubleValue
+textDoubleValue =
+    textWrapper textFloat 0
+
+
+{-| -}
+textFloatValue : Text FloatValue
+textFloatValue =
+    textWrapper textFloat 0
+
+
+{-| -}
+textInt32Value : Text Int32Value
+textInt32Value =
+    textWrapper textInt 0
+
+
+{-| -}
+textInt64Value : Text Int64Value
+textInt64Value =
+    textWrapper textInt 0
+
+
+{-| -}
+textStringValue : Text StringValue
+textStringValue =
+    textWrapper textString ""
+
+
+{-| -}
+textUInt32Value : Text UInt32Value
+textUInt32Value =
+    textWrapper textInt 0
+
+
+{-| -}
+textUInt64Value : Text UInt64Value
+textUInt64Value =
+    textWrapper textInt 0
+
+
+{-| Printed as `{ seconds: 1 nanos: 500000000 }`. Precision is limited to milliseconds.
+-}
+textTimestamp : Text Time.Posix
+textTimestamp =
+    textMessage ( 0, 0 )
+        (\( seconds, nanos ) ->
+            textSingular "seconds" textInt (==) 0 seconds
+                ++ textSingular "nanos" textInt (==) 0 nanos
+        )
+        (\name ->
+            case name of
+                "seconds" ->
+                    parseTextField textInt (\s ( _, n ) -> ( s, n ))
+
+                "nanos" ->
+                    parseTextField textInt (\n ( s, _ ) -> ( s, n ))
+
+                _ ->
+                    textUnknown name
+        )
+        |> textMap
+            (\( seconds, nanos ) -> Time.millisToPosix (seconds * 1000 + nanos // 1000000))
+            (\t ->
+                let
+                    millis =
+                        Time.posixToMillis t
+
+                    -- Nanos are never negative so seconds round down
+                    rem =
+                        modBy 1000 millis
+                in
+                ( (millis - rem) // 1000, rem * 1000000 )
+            )
+
+
+{-| Printed as `{ seconds: 1 nanos: 500000000 }`.
+-}
+textDuration : Text GP.Duration
+textDuration =
+    textMessage emptyDuration
+        (\d ->
+            textSingular "seconds" textInt (==) 0 d.seconds
+                ++ textSingular "nanos" textInt (==) 0 d.nanos
+        )
+        (\name ->
+            case name of
+                "seconds" ->
+                    parseTextField textInt (\s d -> { d | seconds = s })
+
+                "nanos" ->
+                    parseTextField textInt (\n d -> { d | nanos = n })
+
+                _ ->
+                    textUnknown name
+        )
+
+
+{-| Printed as `{}`.
+-}
+textEmpty : Text GP.Empty
+textEmpty =
+    textMessage emptyEmpty (always []) textUnknown
+
+
+textWrapper : Text a -> a -> Text (Maybe a)
+textWrapper codec zero =
+    textMessage zero
+        (\v -> [ ( "value", codec.print v ) ])
+        (\name ->
+            case name of
+                "value" ->
+                    parseTextField codec always
+
+                _ ->
+                    textUnknown name
+        )
+        |> textMap Just (Maybe.withDefault zero)
+
+
+textMap : (a -> b) -> (b -> a) -> Text a -> Text b
+textMap to from codec =
+    { print = from >> codec.print
+    , parse = codec.parse >> Result.map to
+    }
+
+
+textToString : TextValue -> String
+textToString value =
+    case value of
+        TextScalar s ->
+            s
+
+        TextQuoted bytes ->
+            "\"" ++ String.concat (List.map textEscape bytes) ++ "\""
+
+        TextMessage fields ->
+            String.join "\n" (textLines "" fields)
+
+
+textLines : String -> List ( String, TextValue ) -> List String
+textLines indent =
+    List.concatMap
+        (\( name, value ) ->
+            case value of
+                TextMessage [] ->
+                    [ indent ++ name ++ " {}" ]
+
+                TextMessage fields ->
+                    (indent ++ name ++ " {") :: textLines (indent ++ "  ") fields ++ [ indent ++ "}" ]
+
+                _ ->
+                    [ indent ++ name ++ ": " ++ textToString value ]
+        )
+
+
+textEscape : Int -> String
+textEscape b =
+    case b of
+        9 ->
+            "\\t"
+
+        10 ->
+            "\\n"
+
+        13 ->
+            "\\r"
+
+        34 ->
+            "\\\""
+
+        39 ->
+            "\\'"
+
+        92 ->
+            "\\\\"
+
+        _ ->
+            if b >= 32 && b < 127 then
+                String.fromChar (Char.fromCode b)
+
+            else
+                -- Three digit octal
+                "\\" ++ String.concat (List.map String.fromInt [ b // 64, modBy 8 (b // 8), modBy 8 b ])
+
+
+textParseInt : String -> Maybe Int
+textParseInt s =
+    case String.uncons s of
+        Just ( '-', rest ) ->
+            Maybe.map negate (textParseUnsigned rest)
+
+        _ ->
+            textParseUnsigned s
+
+
+textParseUnsigned : String -> Maybe Int
+textParseUnsigned s =
+    if String.startsWith "0x" s || String.startsWith "0X" s then
+        let
+            digits =
+                String.toList (String.dropLeft 2 s)
+        in
+        if not (List.isEmpty digits) && List.all Char.isHexDigit digits then
+            Just (textDigits 16 digits)
+
+        else
+            Nothing
+
+    else if String.all Char.isDigit s && s /= "" then
+        String.toInt s
+
+    else
+        Nothing
+
+
+textParseFloat : String -> Maybe Float
+textParseFloat s =
+    case String.toLower s of
+        "nan" ->
+            Just (0 / 0)
+
+        "inf" ->
+            Just (1 / 0)
+
+        "infinity" ->
+            Just (1 / 0)
+
+        "-inf" ->
+            Just (-1 / 0)
+
+        "-infinity" ->
+            Just (-1 / 0)
+
+        lower ->
+            -- A trailing f is allowed e.g., 1.5f
+            if String.endsWith "f" lower then
+                String.toFloat (String.dropRight 1 lower)
+
+            else
+                String.toFloat lower
+
+
+textDigits : Int -> List Char -> Int
+textDigits base =
+    List.foldl
+        (\c n ->
+            let
+                code =
+                    Char.toCode (Char.toLower c)
+            in
+            if code >= 97 then
+                n * base + code - 87
+
+            else
+                n * base + code - 48
+        )
+        0
+
+
+type TextToken
+    = TokenWord String
+    | TokenQuoted (List Int)
+    | TokenSymbol Char
+
+
+textTokens : List Char -> List TextToken -> Result String (List TextToken)
+textTokens chars acc =
+    case chars of
+        [] ->
+            Ok (List.reverse acc)
+
+        c :: rest ->
+            if c == '#' then
+                textTokens (Tuple.second (textTake (-1) (\x -> x /= '\n') rest [])) acc
+
+            else if c == ' ' || c == '\n' || c == '\t' || c == '\u{000D}' then
+                textTokens rest acc
+
+            else if c == '"' || c == '\'' then
+                case ( textQuoted c rest [], acc ) of
+                    ( Ok ( bytes, after ), (TokenQuoted prev) :: accRest ) ->
+                        -- Adjacent strings are concatenated
+                        textTokens after (TokenQuoted (prev ++ bytes) :: accRest)
+
+                    ( Ok ( bytes, after ), _ ) ->
+                        textTokens after (TokenQuoted bytes :: acc)
+
+                    ( Err err, _ ) ->
+                        Err err
+
+            else if String.contains (String.fromChar c) ":{}<>[],;" then
+                textTokens rest (TokenSymbol c :: acc)
+
+            else if textWordChar c then
+                let
+                    ( word, after ) =
+                        textTake (-1) textWordChar chars []
+                in
+                textTokens after (TokenWord (String.fromList word) :: acc)
+
+            else
+                Err ("unexpected character " ++ String.fromChar c)
+
+
+textWordChar : Char -> Bool
+textWordChar c =
+    Char.isAlphaNum c || c == '_' || c == '-' || c == '.' || c == '+'
+
+
+{-| Takes up to n (or all if negative) matching chars.
+-}
+textTake : Int -> (Char -> Bool) -> List Char -> List Char -> ( List Char, List Char )
+textTake n pred chars acc =
+    case chars of
+        c :: rest ->
+            if n /= 0 && pred c then
+                textTake (n - 1) pred rest (c :: acc)
+
+            else
+                ( List.reverse acc, chars )
+
+        [] ->
+            ( List.reverse acc, chars )
+
+
+{-| Reads a quoted string up to its closing quote. Bytes are accumulated in reverse.
+-}
+textQuoted : Char -> List Char -> List Int -> Result String ( List Int, List Char )
+textQuoted quote chars acc =
+    case chars of
+        [] ->
+            Err "unterminated string"
+
+        '\\' :: c :: rest ->
+            if c == 'x' || c == 'X' then
+                case textTake 2 Char.isHexDigit rest [] of
+                    ( [], _ ) ->
+                        Err "invalid hex escape"
+
+                    ( digits, after ) ->
+                        textQuoted quote after (textDigits 16 digits :: acc)
+
+            else if Char.isOctDigit c then
+                let
+                    ( digits, after ) =
+                        textTake 3 Char.isOctDigit (c :: rest) []
+                in
+                textQuoted quote after (modBy 256 (textDigits 8 digits) :: acc)
+
+            else
+                case textEscapeChar c of
+                    Just b ->
+                        textQuoted quote rest (b :: acc)
+
+                    Nothing ->
+                        Err ("invalid escape \\" ++ String.fromChar c)
+
+        c :: rest ->
+            if c == quote then
+                Ok ( List.reverse acc, rest )
+
+            else if c == '\n' then
+                Err "unterminated string"
+
+            else
+                textQuoted quote rest (List.reverse (bytesToList (BE.encode (BE.string (String.fromChar c)))) ++ acc)
+
+
+textEscapeChar : Char -> Maybe Int
+textEscapeChar c =
+    case c of
+        'a' ->
+            Just 7
+
+        'b' ->
+            Just 8
+
+        'f' ->
+            Just 12
+
+        'n' ->
+            Just 10
+
+        'r' ->
+            Just 13
+
+        't' ->
+            Just 9
+
+        'v' ->
+            Just 11
+
+        '?' ->
+            Just 63
+
+        '"' ->
+            Just 34
+
+        '\'' ->
+            Just 39
+
+        '\\' ->
+            Just 92
+
+        _ ->
+            Nothing
+
+
+textFields : List TextToken -> List ( String, TextValue ) -> Result String ( List ( String, TextValue ), List TextToken )
+textFields tokens acc =
+    case tokens of
+        (TokenWord name) :: rest ->
+            let
+                afterColon =
+                    case rest of
+                        (TokenSymbol ':') :: more ->
+                            more
+
+                        _ ->
+                            rest
+            in
+            case textFieldValues afterColon of
+                Ok ( values, after ) ->
+                    textFields (textSeparator after) (List.reverse (List.map (Tuple.pair name) values) ++ acc)
+
+                Err err ->
+                    Err err
+
+        (TokenSymbol '}') :: _ ->
+            Ok ( List.reverse acc, tokens )
+
+        (TokenSymbol '>') :: _ ->
+            Ok ( List.reverse acc, tokens )
+
+        [] ->
+            Ok ( List.reverse acc, tokens )
+
+        _ ->
+            Err "expected a field name"
+
+
+textSeparator : List TextToken -> List TextToken
+textSeparator tokens =
+    case tokens of
+        (TokenSymbol ',') :: rest ->
+            rest
+
+        (TokenSymbol ';') :: rest ->
+            rest
+
+        _ ->
+            tokens
+
+
+textFieldValues : List TextToken -> Result String ( List TextValue, List TextToken )
+textFieldValues tokens =
+    case tokens of
+        (TokenSymbol '[') :: (TokenSymbol ']') :: rest ->
+            Ok ( [], rest )
+
+        (TokenSymbol '[') :: rest ->
+            textList rest []
+
+        _ ->
+            textValue tokens |> Result.map (Tuple.mapFirst List.singleton)
+
+
+textList : List TextToken -> List TextValue -> Result String ( List TextValue, List TextToken )
+textList tokens acc =
+    case textValue tokens of
+        Ok ( v, (TokenSymbol ',') :: rest ) ->
+            textList rest (v :: acc)
+
+        Ok ( v, (TokenSymbol ']') :: rest ) ->
+            Ok ( List.reverse (v :: acc), rest )
+
+        Ok _ ->
+            Err "expected , or ] in list"
+
+        Err err ->
+            Err err
+
+
+textValue : List TextToken -> Result String ( TextValue, List TextToken )
+textValue tokens =
+    case tokens of
+        (TokenSymbol '{') :: rest ->
+            textNested '}' rest
+
+        (TokenSymbol '<') :: rest ->
+            textNested '>' rest
+
+        (TokenWord word) :: rest ->
+            Ok ( TextScalar word, rest )
+
+        (TokenQuoted bytes) :: rest ->
+            Ok ( TextQuoted bytes, rest )
+
+        _ ->
+            Err "expected a value"
+
+
+textNested : Char -> List TextToken -> Result String ( TextValue, List TextToken )
+textNested close tokens =
+    case textFields tokens [] of
+        Ok ( fields, (TokenSymbol c) :: rest ) ->
+            if c == close then
+                Ok ( TextMessage fields, rest )
+
+            else
+                Err ("expected " ++ String.fromChar close)
+
+        Ok _ ->
+            Err ("expected " ++ String.fromChar close)
+
+        Err err ->
+            Err err
+
+
+bytesToList : Bytes -> List Int
+bytesToList bytes =
+    BD.decode (BD.loop ( Bytes.width bytes, [] ) bytesToListStep) bytes
+        |> Maybe.withDefault []
+
+
+bytesToListStep : ( Int, List Int ) -> BD.Decoder (BD.Step ( Int, List Int ) (List Int))
+bytesToListStep ( remaining, acc ) =
+    if remaining > 0 then
+        BD.map (\b -> BD.Loop ( remaining - 1, b :: acc )) BD.unsignedInt8
+
+    else
+        BD.succeed (BD.Done (List.reverse acc))
+
+
+listToBytes : List Int -> Bytes
+listToBytes =
+    List.map BE.unsignedInt8 >> BE.sequence >> BE.encode
+
+
+
+-- Base64
+
+
+alphabet : String
+alphabet =
+    "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+
+{-| Encodes bytes to a padded, standard base64 string.
+-}
+toBase64 : Bytes -> String
+toBase64 bytes =
+    BD.decode (BD.loop ( Bytes.width bytes, [] ) toBase64Step) bytes
+        |> Maybe.withDefault []
+        |> List.reverse
+        |> String.concat
+
+
+toBase64Step : ( Int, List String ) -> BD.Decoder (BD.Step ( Int, List String ) (List String))
+toBase64Step ( remaining, acc ) =
+    if remaining >= 3 then
+        BD.map3 (\a b c -> BD.Loop ( remaining - 3, toBase64Chunk 4 a b c :: acc ))
+            BD.unsignedInt8
+            BD.unsignedInt8
+            BD.unsignedInt8
+
+    else if remaining == 2 then
+        BD.map2 (\a b -> BD.Done (toBase64Chunk 3 a b 0 :: acc))
+            BD.unsignedInt8
+            BD.unsignedInt8
+
+    else if remaining == 1 then
+        BD.map (\a -> BD.Done (toBase64Chunk 2 a 0 0 :: acc)) BD.unsignedInt8
+
+    else
+        BD.succeed (BD.Done acc)
+
+
+{-| Converts three bytes to four characters of which only n are kept. The rest are padded.
+-}
+toBase64Chunk : Int -> Int -> Int -> Int -> String
+toBase64Chunk n a b c =
+    let
+        v =
+            a * 65536 + b * 256 + c
+
+        char i =
+            String.slice i (i + 1) alphabet
+    in
+    [ v // 262144, modBy 64 (v // 4096), modBy 64 (v // 64), modBy 64 v ]
+        |> List.take n
+        |> List.map char
+        |> String.concat
+        |> (\s -> s ++ String.repeat (4 - n) "=")
+
+
+{-| Decodes a standard base64 string. Padding is optional.
+-}
+fromBase64 : String -> Maybe Bytes
+fromBase64 str =
+    String.toList str
+        |> List.filter ((/=) '=')
+        |> List.foldr (\c acc -> Maybe.map2 (::) (fromBase64Char c) acc) (Just [])
+        |> Maybe.andThen (fromBase64Values [])
+        |> Maybe.map (List.reverse >> List.map BE.unsignedInt8 >> BE.sequence >> BE.encode)
+
+
+fromBase64Values : List Int -> List Int -> Maybe (List Int)
+fromBase64Values acc values =
+    case values of
+        a :: b :: c :: d :: rest ->
+            let
+                v =
+                    a * 262144 + b * 4096 + c * 64 + d
+            in
+            fromBase64Values (modBy 256 v :: modBy 256 (v // 256) :: v // 65536 :: acc) rest
+
+        [ a, b, c ] ->
+            let
+                v =
+                    a * 262144 + b * 4096 + c * 64
+            in
+            Just (modBy 256 (v // 256) :: v // 65536 :: acc)
+
+        [ a, b ] ->
+            Just ((a * 262144 + b * 4096) // 65536 :: acc)
+
+        [] ->
+            Just acc
+
+        _ ->
+            Nothing
+
+
+fromBase64Char : Char -> Maybe Int
+fromBase64Char c =
+    let
+        code =
+            Char.toCode c
+    in
+    if Char.isUpper c then
+        Just (code - 65)
+
+    else if Char.isLower c then
+        Just (code - 71)
+
+    else if Char.isDigit c then
+        Just (code + 4)
+
+    else if c == '+' || c == '-' then
+        Just 62
+
+    else if c == '/' || c == '_' then
+        Just 63
+
+    else
+        Nothing
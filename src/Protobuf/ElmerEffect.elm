@@ -0,0 +1,107 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+
+module Protobuf.ElmerEffect exposing
+    ( Rpc
+    , toCmd, respond, resolveBytes
+    )
+
+{-| Helper types and functions for `protoc-gen-elmer-twirp` codegen when the `effects=t` option is passed. Each generated `Effect` describes an RPC call as data so that it can be inspected in tests before being performed.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Types
+
+@docs Rpc
+
+
+# Interpreting
+
+@docs toCmd, respond, resolveBytes
+
+-}
+
+import Bytes exposing (Bytes)
+import Http
+import Protobuf.Decode as PD
+
+
+
+-- Types
+
+
+{-| Describes a Twirp RPC call. The request is an encoded Protobuf message. Respond turns the raw HTTP response into a message, decoding the RPC's output along the way.
+-}
+type alias Rpc msg =
+    { service : String
+    , method : String
+    , request : Bytes
+    , respond : Http.Response Bytes -> msg
+    }
+
+
+
+-- Interpreting
+
+
+{-| Performs an RPC call over HTTP against a Twirp server at the given URL prefix.
+-}
+toCmd : String -> Rpc msg -> Cmd msg
+toCmd api rpc =
+    Http.riskyRequest
+        { method = "POST"
+        , headers = []
+        , url = api ++ "/" ++ rpc.service ++ "/" ++ rpc.method
+        , body = Http.bytesBody "application/protobuf" rpc.request
+        , expect = Http.expectBytesResponse fromOk (rpc.respond >> Ok)
+        , timeout = Nothing
+        , tracker = Nothing
+        }
+
+
+fromOk : Result Never a -> a
+fromOk result =
+    case result of
+        Ok a ->
+            a
+
+        Err err ->
+            never err
+
+
+{-| Creates a responder that decodes a Protobuf message. Matches the result of the generated RPC functions.
+-}
+respond : (Result Http.Error a -> msg) -> PD.Decoder a -> Http.Response Bytes -> msg
+respond toMsg decoder =
+    resolveBytes
+        >> Result.andThen (PD.decode decoder >> Result.fromMaybe (Http.BadBody "Failed to decode Protobuf message"))
+        >> toMsg
+
+
+{-| Turns a raw HTTP response into its body. Errors follow `Http.expectBytes`.
+-}
+resolveBytes : Http.Response Bytes -> Result Http.Error Bytes
+resolveBytes response =
+    case response of
+        Http.BadUrl_ url ->
+            Err (Http.BadUrl url)
+
+        Http.Timeout_ ->
+            Err Http.Timeout
+
+        Http.NetworkError_ ->
+            Err Http.NetworkError
+
+        Http.BadStatus_ metadata _ ->
+            Err (Http.BadStatus metadata.statusCode)
+
+        Http.GoodStatus_ _ bytes ->
+            Ok bytes
@@ -0,0 +1,583 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+
+module Protobuf.ElmerExplorer exposing
+    ( Page, page, httpError
+    , record, field, oneof, choose
+    , list, maybe, dict, assoc
+    , bool, int, float, string, bytes, enum, select, json, readOnly
+    , formAny, formApi, formBoolValue, formBytesValue, formDoubleValue, formDuration, formEmpty, formEnum, formEnumValue, formField, formFieldMask, formField_Cardinality, formField_Kind, formFloatValue, formInt32Value, formInt64Value, formListValue, formMethod, formMixin, formNullValue, formOption, formSourceContext, formStringValue, formStruct, formSyntax, formTimestamp, formUInt32Value, formUInt64Value, formValue, formXType
+    )
+
+{-| Helper types and functions for `protoc-gen-elmer-explorer` codegen. Forms are views that produce an edited copy of their value e.g., `formFoo : Foo -> Html Foo`, so they can be nested without any extra state.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Page
+
+@docs Page, page, httpError
+
+
+# Messages
+
+@docs record, field, oneof, choose
+
+
+# Containers
+
+@docs list, maybe, dict, assoc
+
+
+# Scalars
+
+@docs bool, int, float, string, bytes, enum, select, json, readOnly
+
+
+# Well-known types
+
+Types with a JSON mapping (e.g., `Timestamp`) are edited as JSON. The rest are read only.
+
+@docs formAny, formApi, formBoolValue, formBytesValue, formDoubleValue, formDuration, formEmpty, formEnum, formEnumValue, formField, formFieldMask, formField_Cardinality, formField_Kind, formFloatValue, formInt32Value, formInt64Value, formListValue, formMethod, formMixin, formNullValue, formOption, formSourceContext, formStringValue, formStruct, formSyntax, formTimestamp, formUInt32Value, formUInt64Value, formValue, formXType
+
+-}
+
+import Bytes exposing (Bytes)
+import Bytes.Decode as BD
+import Bytes.Encode as BE
+import Dict exposing (Dict)
+import Google.Protobuf as GP
+import Html exposing (Html)
+import Html.Attributes as HA
+import Html.Events as HE
+import Http
+import Json.Decode as JD
+import Json.Encode as JE
+import Protobuf.Elmer as Elmer
+import Protobuf.ElmerJson as ElmerJson
+import Protobuf.ElmerView as ElmerView
+import Time
+
+
+
+-- Page
+
+
+{-| Everything shown by an explorer page. The method picker and form are already mapped to messages.
+-}
+type alias Page msg =
+    { api : String
+    , onApi : String -> msg
+    , methods : Html msg
+    , form : Html msg
+    , onSend : msg
+    , loading : Bool
+    , response : Maybe (Result Http.Error (Html msg))
+    }
+
+
+{-| Lays out an explorer: the server's URL prefix, a method picker, the request form and the last response.
+-}
+page : Page msg -> Html msg
+page p =
+    Html.div [ HA.style "font-family" "monospace" ]
+        [ Html.p []
+            [ Html.label [] [ Html.text "API " ]
+            , Html.input [ HA.value p.api, HA.placeholder "https://example.com/twirp", HE.onInput p.onApi ] []
+            ]
+        , Html.p [] [ Html.label [] [ Html.text "Method " ], p.methods ]
+        , p.form
+        , Html.p []
+            [ Html.button [ HA.type_ "button", HA.disabled p.loading, HE.onClick p.onSend ]
+                [ Html.text "Send" ]
+            ]
+        , case p.response of
+            Nothing ->
+                Html.text ""
+
+            Just (Ok response) ->
+                response
+
+            Just (Err err) ->
+                Html.p [ HA.style "color" "red" ] [ Html.text (httpError err) ]
+        ]
+
+
+{-| Describes a failed request.
+-}
+httpError : Http.Error -> String
+httpError err =
+    case err of
+        Http.BadUrl url ->
+            "Bad URL: " ++ url
+
+        Http.Timeout ->
+            "Timed out"
+
+        Http.NetworkError ->
+            "Network error"
+
+        Http.BadStatus status ->
+            "Bad status: " ++ String.fromInt status
+
+        Http.BadBody body ->
+            "Bad body: " ++ body
+
+
+
+-- Messages
+
+
+{-| A message's fields labelled by its full Protobuf name e.g., `my.pkg.Foo`.
+-}
+record : String -> List (Html a) -> Html a
+record name fields =
+    Html.fieldset []
+        (Html.legend [] [ Html.text name ] :: fields)
+
+
+{-| A message field labelled with its Protobuf name and number. Edits are set on the message.
+-}
+field : String -> Int -> (a -> r) -> Html a -> Html r
+field name number set control =
+    row (name ++ " (" ++ String.fromInt number ++ ")") (Html.map set control)
+
+
+{-| A oneof labelled with its Protobuf name. See `choose`.
+-}
+oneof : String -> (a -> r) -> Html a -> Html r
+oneof name set control =
+    row name (Html.map set control)
+
+
+{-| Picks which field of a oneof is set. Choices are labelled and hold the zero value of their field. The form of the chosen field follows.
+-}
+choose : List ( String, a ) -> Maybe String -> Html (Maybe a) -> Html (Maybe a)
+choose choices chosen form =
+    let
+        option i ( name, _ ) =
+            Html.option [ HA.value (String.fromInt i), HA.selected (Just name == chosen) ]
+                [ Html.text name ]
+
+        pick s =
+            String.toInt s
+                |> Maybe.andThen (\i -> List.head (List.drop i choices))
+                |> Maybe.map Tuple.second
+    in
+    Html.div []
+        [ Html.select [ HE.onInput pick ]
+            (Html.option [ HA.value "", HA.selected (chosen == Nothing) ] [ Html.text "not set" ]
+                :: List.indexedMap option choices
+            )
+        , form
+        ]
+
+
+row : String -> Html a -> Html a
+row name control =
+    Html.div [ HA.style "margin" "0.25em 0" ]
+        [ Html.label [ HA.style "color" "gray" ] [ Html.text (name ++ ": ") ]
+        , control
+        ]
+
+
+
+-- Containers
+
+
+{-| Items can be edited, removed or added (as the zero value).
+-}
+list : a -> (a -> Html a) -> List a -> Html (List a)
+list zero form items =
+    let
+        item i v =
+            Html.div []
+                [ Html.map (\x -> List.take i items ++ x :: List.drop (i + 1) items) (form v)
+                , button "−" (List.take i items ++ List.drop (i + 1) items)
+                ]
+    in
+    Html.div []
+        (List.indexedMap item items ++ [ button "+" (items ++ [ zero ]) ])
+
+
+{-| A checkbox for whether the value is present followed by its form.
+-}
+maybe : a -> (a -> Html a) -> Maybe a -> Html (Maybe a)
+maybe zero form value =
+    let
+        present =
+            Html.map
+                (\set ->
+                    if set then
+                        Just (Maybe.withDefault zero value)
+
+                    else
+                        Nothing
+                )
+    in
+    case value of
+        Just v ->
+            Html.span [] [ present (bool True), Html.map Just (form v) ]
+
+        Nothing ->
+            Html.span [] [ present (bool False) ]
+
+
+{-| Map entries. Editing a key to an existing key replaces that entry.
+-}
+dict : comparable -> v -> (comparable -> Html comparable) -> (v -> Html v) -> Dict comparable v -> Html (Dict comparable v)
+dict keyZero valZero keyForm valForm entries =
+    assoc keyZero valZero keyForm valForm (Dict.toList entries)
+        |> Html.map Dict.fromList
+
+
+{-| Map entries for keys that aren't `comparable` e.g., bools.
+-}
+assoc : k -> v -> (k -> Html k) -> (v -> Html v) -> List ( k, v ) -> Html (List ( k, v ))
+assoc keyZero valZero keyForm valForm =
+    list ( keyZero, valZero )
+        (\( k, v ) ->
+            Html.span []
+                [ Html.map (\x -> ( x, v )) (keyForm k)
+                , Html.text " → "
+                , Html.map (\x -> ( k, x )) (valForm v)
+                ]
+        )
+
+
+button : String -> a -> Html a
+button name value =
+    Html.button [ HA.type_ "button", HE.onClick value ] [ Html.text name ]
+
+
+
+-- Scalars
+
+
+{-| -}
+bool : Bool -> Html Bool
+bool b =
+    Html.input [ HA.type_ "checkbox", HA.checked b, HE.onCheck identity ] []
+
+
+{-| Input that can't be parsed is ignored.
+-}
+int : Int -> Html Int
+int i =
+    Html.input
+        [ HA.type_ "number"
+        , HA.step "1"
+        , HA.value (String.fromInt i)
+        , HE.onInput (String.toInt >> Maybe.withDefault i)
+        ]
+        []
+
+
+{-| Input that can't be parsed is ignored.
+-}
+float : Float -> Html Float
+float f =
+    Html.input
+        [ HA.type_ "number"
+        , HA.step "any"
+        , HA.value (String.fromFloat f)
+        , HE.onInput (String.toFloat >> Maybe.withDefault f)
+        ]
+        []
+
+
+{-| -}
+string : String -> Html String
+string s =
+    Html.input [ HA.value s, HE.onInput identity ] []
+
+
+{-| Edited as hex e.g., `0a ff`. Input that can't be parsed is ignored.
+-}
+bytes : Bytes -> Html Bytes
+bytes b =
+    Html.input
+        [ HA.value (toHex b)
+        , HA.placeholder "hex"
+        , HE.onInput (fromHex >> Maybe.withDefault b)
+        ]
+        []
+
+
+toHex : Bytes -> String
+toHex b =
+    let
+        step ( n, acc ) =
+            if n <= 0 then
+                BD.succeed (BD.Done (List.reverse acc))
+
+            else
+                BD.map (\byte -> BD.Loop ( n - 1, hexDigit (byte // 16) ++ hexDigit (modBy 16 byte) :: acc ))
+                    BD.unsignedInt8
+    in
+    BD.decode (BD.loop ( Bytes.width b, [] ) step) b
+        |> Maybe.withDefault []
+        |> String.join " "
+
+
+hexDigit : Int -> String
+hexDigit i =
+    String.slice i (i + 1) "0123456789abcdef"
+
+
+fromHex : String -> Maybe Bytes
+fromHex str =
+    let
+        value c =
+            List.head (String.indexes (String.fromChar c) "0123456789abcdef")
+
+        pairs digits acc =
+            case digits of
+                [] ->
+                    Just (BE.encode (BE.sequence (List.map BE.unsignedInt8 (List.reverse acc))))
+
+                (Just hi) :: (Just lo) :: rest ->
+                    pairs rest ((hi * 16 + lo) :: acc)
+
+                _ ->
+                    Nothing
+    in
+    pairs (List.map value (String.toList (String.toLower (String.replace " " "" str)))) []
+
+
+{-| Picks an enum value by its Protobuf label.
+-}
+enum : (a -> String) -> List a -> a -> Html a
+enum toLabel values =
+    select (List.map (\v -> ( toLabel v, v )) values)
+
+
+{-| Picks one of the labelled options.
+-}
+select : List ( String, a ) -> a -> Html a
+select options value =
+    let
+        option i ( name, v ) =
+            Html.option [ HA.value (String.fromInt i), HA.selected (v == value) ] [ Html.text name ]
+
+        pick s =
+            String.toInt s
+                |> Maybe.andThen (\i -> List.head (List.drop i options))
+                |> Maybe.map Tuple.second
+                |> Maybe.withDefault value
+    in
+    Html.select [ HE.onInput pick ] (List.indexedMap option options)
+
+
+{-| Edited as JSON. Input that can't be parsed is ignored.
+-}
+json : JD.Decoder a -> (a -> JE.Value) -> a -> Html a
+json decoder encoder value =
+    Html.input
+        [ HA.value (JE.encode 0 (encoder value))
+        , HE.onInput (JD.decodeString decoder >> Result.withDefault value)
+        ]
+        []
+
+
+{-| Shows a value that can't be edited.
+-}
+readOnly : (a -> Html Never) -> a -> Html a
+readOnly view value =
+    Html.map never (view value)
+
+
+
+-- Well-known types
+
+
+{-| -}
+formBoolValue : Elmer.BoolValue -> Html Elmer.BoolValue
+formBoolValue =
+    json ElmerJson.decodeBoolValue ElmerJson.encodeBoolValue
+
+
+{-| -}
+formBytesValue : Elmer.BytesValue -> Html Elmer.BytesValue
+formBytesValue =
+    json ElmerJson.decodeBytesValue ElmerJson.encodeBytesValue
+
+
+{-| -}
+formDoubleValue : Elmer.DoubleValue -> Html Elmer.DoubleValue
+formDoubleValue =
+    json ElmerJson.decodeDoubleValue ElmerJson.encodeDoubleValue
+
+
+{-| -}
+formDuration : GP.Duration -> Html GP.Duration
+formDuration =
+    json ElmerJson.decodeDuration ElmerJson.encodeDuration
+
+
+{-| -}
+formEmpty : GP.Empty -> Html GP.Empty
+formEmpty =
+    json ElmerJson.decodeEmpty ElmerJson.encodeEmpty
+
+
+{-| -}
+formFieldMask : GP.FieldMask -> Html GP.FieldMask
+formFieldMask =
+    json ElmerJson.decodeFieldMask ElmerJson.encodeFieldMask
+
+
+{-| -}
+formFloatValue : Elmer.FloatValue -> Html Elmer.FloatValue
+formFloatValue =
+    json ElmerJson.decodeFloatValue ElmerJson.encodeFloatValue
+
+
+{-| -}
+formInt32Value : Elmer.Int32Value -> Html Elmer.Int32Value
+formInt32Value =
+    json ElmerJson.decodeInt32Value ElmerJson.encodeInt32Value
+
+
+{-| -}
+formInt64Value : Elmer.Int64Value -> Html Elmer.Int64Value
+formInt64Value =
+    json ElmerJson.decodeInt64Value ElmerJson.encodeInt64Value
+
+
+{-| -}
+formListValue : GP.ListValue -> Html GP.ListValue
+formListValue =
+    json ElmerJson.decodeListValue ElmerJson.encodeListValue
+
+
+{-| -}
+formNullValue : GP.NullValue -> Html GP.NullValue
+formNullValue =
+    json ElmerJson.decodeNullValue ElmerJson.encodeNullValue
+
+
+{-| -}
+formStringValue : Elmer.StringValue -> Html Elmer.StringValue
+formStringValue =
+    json ElmerJson.decodeStringValue ElmerJson.encodeStringValue
+
+
+{-| -}
+formStruct : GP.Struct -> Html GP.Struct
+formStruct =
+    json ElmerJson.decodeStruct ElmerJson.encodeStruct
+
+
+{-| RFC 3339 e.g., `"1970-01-01T00:00:00Z"`.
+-}
+formTimestamp : Time.Posix -> Html Time.Posix
+formTimestamp =
+    json ElmerJson.decodeTimestamp ElmerJson.encodeTimestamp
+
+
+{-| -}
+formUInt32Value : Elmer.UInt32Value -> Html Elmer.UInt32Value
+formUInt32Value =
+    json ElmerJson.decodeUInt32Value ElmerJson.encodeUInt32Value
+
+
+{-| -}
+formUInt64Value : Elmer.UInt64Value -> Html Elmer.UInt64Value
+formUInt64Value =
+    json ElmerJson.decodeUInt64Value ElmerJson.encodeUInt64Value
+
+
+{-| -}
+formValue : GP.Value -> Html GP.Value
+formValue =
+    json ElmerJson.decodeValue ElmerJson.encodeValue
+
+
+
+-- Read only Google.Protobuf pass through
+
+
+{-| -}
+formAny : GP.Any -> Html GP.Any
+formAny =
+    readOnly ElmerView.viewAny
+
+
+{-| -}
+formApi : GP.Api -> Html GP.Api
+formApi =
+    readOnly ElmerView.viewApi
+
+
+{-| -}
+formEnum : GP.Enum -> Html GP.Enum
+formEnum =
+    readOnly ElmerView.viewEnum
+
+
+{-| -}
+formEnumValue : GP.EnumValue -> Html GP.EnumValue
+formEnumValue =
+    readOnly ElmerView.viewEnumValue
+
+
+{-| -}
+formField : GP.Field -> Html GP.Field
+formField =
+    readOnly ElmerView.viewField
+
+
+{-| -}
+formField_Cardinality : GP.Cardinality -> Html GP.Cardinality
+formField_Cardinality =
+    readOnly ElmerView.viewField_Cardinality
+
+
+{-| -}
+formField_Kind : GP.Kind -> Html GP.Kind
+formField_Kind =
+    readOnly ElmerView.viewField_Kind
+
+
+{-| -}
+formMethod : GP.Method -> Html GP.Method
+formMethod =
+    readOnly ElmerView.viewMethod
+
+
+{-| -}
+formMixin : GP.Mixin -> Html GP.Mixin
+formMixin =
+    readOnly ElmerView.viewMixin
+
+
+{-| -}
+formOption : GP.Option -> Html GP.Option
+formOption =
+    readOnly ElmerView.viewOption
+
+
+{-| -}
+formSourceContext : GP.SourceContext -> Html GP.SourceContext
+formSourceContext =
+    readOnly ElmerView.viewSourceContext
+
+
+{-| -}
+formSyntax : GP.Syntax -> Html GP.Syntax
+formSyntax =
+    readOnly ElmerView.viewSyntax
+
+
+{-| -}
+formXType : GP.Type -> Html GP.Type
+formXType =
+    readOnly ElmerView.viewXType
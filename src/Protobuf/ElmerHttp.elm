@@ -0,0 +1,127 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+module Protobuf.ElmerHttp exposing
+    ( Config, defaultConfig
+    , Response, Error
+    , expectResponse, resolve, stubMetadata
+    )
+
+{-| Helper types and functions for `protoc-gen-elmer-twirp` codegen. Configures generated service clients. When the `metadata=t` option is passed, RPC results keep the HTTP response's metadata e.g., headers and status code.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Clients
+
+@docs Config, defaultConfig
+
+
+# Types
+
+@docs Response, Error
+
+
+# Responses
+
+@docs expectResponse, resolve, stubMetadata
+
+-}
+
+import Bytes exposing (Bytes)
+import Dict
+import Http
+import Protobuf.Decode as PD
+
+
+
+-- Clients
+
+
+{-| Applied to every request made by a service client.
+-}
+type alias Config =
+    { headers : List Http.Header
+    , timeout : Maybe Float
+    }
+
+
+{-| No extra headers and no timeout.
+-}
+defaultConfig : Config
+defaultConfig =
+    Config [] Nothing
+
+
+
+-- Types
+
+
+{-| A successful RPC call. The body is the decoded Protobuf message.
+-}
+type alias Response body =
+    { metadata : Http.Metadata
+    , body : body
+    }
+
+
+{-| A failed RPC call. Metadata is only available if the server responded.
+-}
+type alias Error =
+    { metadata : Maybe Http.Metadata
+    , error : Http.Error
+    }
+
+
+
+-- Responses
+
+
+{-| Expects a Protobuf message, keeping the response's metadata.
+-}
+expectResponse : (Result Error (Response a) -> msg) -> PD.Decoder a -> Http.Expect msg
+expectResponse toMsg decoder =
+    Http.expectBytesResponse toMsg (resolve decoder)
+
+
+{-| Resolves a raw HTTP response to a Protobuf message.
+-}
+resolve : PD.Decoder a -> Http.Response Bytes -> Result Error (Response a)
+resolve decoder response =
+    case response of
+        Http.BadUrl_ url ->
+            Err (Error Nothing (Http.BadUrl url))
+
+        Http.Timeout_ ->
+            Err (Error Nothing Http.Timeout)
+
+        Http.NetworkError_ ->
+            Err (Error Nothing Http.NetworkError)
+
+        Http.BadStatus_ metadata _ ->
+            Err (Error (Just metadata) (Http.BadStatus metadata.statusCode))
+
+        Http.GoodStatus_ metadata bytes ->
+            case PD.decode decoder bytes of
+                Just body ->
+                    Ok (Response metadata body)
+
+                Nothing ->
+                    Err (Error (Just metadata) (Http.BadBody "Failed to decode Protobuf message"))
+
+
+{-| Metadata of a successful response. Used by stubbed service clients.
+-}
+stubMetadata : Http.Metadata
+stubMetadata =
+    { url = ""
+    , statusCode = 200
+    , statusText = "OK"
+    , headers = Dict.empty
+    }
@@ -0,0 +1,802 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+module Protobuf.ElmerJson exposing
+    ( andMap, required, optional, present, encodeOptional
+    , decodeInt, decodeFloat, decodeBytes, decodeDict, decodeBoolKeys, encodeInt64, encodeFloat, encodeBytes, encodeDict, encodeBoolKeys
+    , decodeBoolValue, decodeBytesValue, decodeDoubleValue, decodeDuration, decodeEmpty, decodeFieldMask, decodeFloatValue, decodeInt32Value, decodeInt64Value, decodeListValue, decodeNullValue, decodeStringValue, decodeStruct, decodeTimestamp, decodeUInt32Value, decodeUInt64Value, decodeValue
+    , encodeBoolValue, encodeBytesValue, encodeDoubleValue, encodeDuration, encodeEmpty, encodeFieldMask, encodeFloatValue, encodeInt32Value, encodeInt64Value, encodeListValue, encodeNullValue, encodeStringValue, encodeStruct, encodeTimestamp, encodeUInt32Value, encodeUInt64Value, encodeValue
+    )
+
+{-| Helper functions for JSON codecs generated by `protoc-gen-elmer-rest`. Follows Protobuf's JSON mapping: fields are named in lowerCamelCase (original names are accepted), missing and null fields take their zero value, 64-bit integers are strings and bytes are base64.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Fields
+
+@docs andMap, required, optional, present, encodeOptional
+
+
+# Scalars
+
+@docs decodeInt, decodeFloat, decodeBytes, decodeDict, decodeBoolKeys, encodeInt64, encodeFloat, encodeBytes, encodeDict, encodeBoolKeys
+
+
+# Well-known type decoders
+
+@docs decodeBoolValue, decodeBytesValue, decodeDoubleValue, decodeDuration, decodeEmpty, decodeFieldMask, decodeFloatValue, decodeInt32Value, decodeInt64Value, decodeListValue, decodeNullValue, decodeStringValue, decodeStruct, decodeTimestamp, decodeUInt32Value, decodeUInt64Value, decodeValue
+
+
+# Well-known type encoders
+
+@docs encodeBoolValue, encodeBytesValue, encodeDoubleValue, encodeDuration, encodeEmpty, encodeFieldMask, encodeFloatValue, encodeInt32Value, encodeInt64Value, encodeListValue, encodeNullValue, encodeStringValue, encodeStruct, encodeTimestamp, encodeUInt32Value, encodeUInt64Value, encodeValue
+
+-}
+
+import Bytes exposing (Bytes)
+import Dict exposing (Dict)
+import Google.Protobuf as GP
+import Json.Decode as JD
+import Json.Encode as JE
+import Protobuf.Elmer as Elmer
+import Time
+
+
+
+-- Fields
+
+
+{-| Pipes a decoded value into a record constructor.
+-}
+andMap : JD.Decoder a -> JD.Decoder (a -> b) -> JD.Decoder b
+andMap =
+    JD.map2 (|>)
+
+
+{-| Decodes a field by its JSON name or its original Protobuf name. Missing and null fields take the default (zero) value.
+-}
+required : String -> String -> JD.Decoder a -> a -> JD.Decoder (a -> b) -> JD.Decoder b
+required jsonName protoName decoder default =
+    andMap (field jsonName protoName decoder |> JD.map (Maybe.withDefault default))
+
+
+{-| Decodes an optional field. Missing and null fields are `Nothing`.
+-}
+optional : String -> String -> JD.Decoder a -> JD.Decoder (Maybe a -> b) -> JD.Decoder b
+optional jsonName protoName decoder =
+    andMap (field jsonName protoName decoder)
+
+
+{-| Decodes a field that must be present. Used to pick a oneof's variant.
+-}
+present : String -> String -> JD.Decoder a -> JD.Decoder a
+present jsonName protoName decoder =
+    field jsonName protoName decoder
+        |> JD.andThen
+            (\maybe ->
+                case maybe of
+                    Just v ->
+                        JD.succeed v
+
+                    Nothing ->
+                        JD.fail ("missing field: " ++ jsonName)
+            )
+
+
+{-| Missing or null fields are `Nothing`. Present fields must decode.
+-}
+field : String -> String -> JD.Decoder a -> JD.Decoder (Maybe a)
+field jsonName protoName decoder =
+    JD.maybe (JD.oneOf [ JD.field jsonName JD.value, JD.field protoName JD.value ])
+        |> JD.andThen
+            (\raw ->
+                case raw of
+                    Just value ->
+                        case JD.decodeValue (JD.nullable decoder) value of
+                            Ok v ->
+                                JD.succeed v
+
+                            Err err ->
+                                JD.fail (JD.errorToString err)
+
+                    Nothing ->
+                        JD.succeed Nothing
+            )
+
+
+{-| Encodes an optional field. `Nothing` is omitted.
+-}
+encodeOptional : String -> (a -> JE.Value) -> Maybe a -> List ( String, JE.Value )
+encodeOptional name encoder =
+    Maybe.map (\v -> [ ( name, encoder v ) ]) >> Maybe.withDefault []
+
+
+
+-- Scalars
+
+
+{-| Integers may be numbers or strings. 64-bit integers are always encoded as strings.
+-}
+decodeInt : JD.Decoder Int
+decodeInt =
+    JD.oneOf
+        [ JD.int
+        , JD.string
+            |> JD.andThen
+                (\s ->
+                    case String.toInt s of
+                        Just i ->
+                            JD.succeed i
+
+                        Nothing ->
+                            JD.fail ("invalid integer: " ++ s)
+                )
+        ]
+
+
+{-| Floats may be numbers or the strings "NaN", "Infinity" and "-Infinity".
+-}
+decodeFloat : JD.Decoder Float
+decodeFloat =
+    JD.oneOf
+        [ JD.float
+        , JD.string
+            |> JD.andThen
+                (\s ->
+                    case ( s, String.toFloat s ) of
+                        ( "NaN", _ ) ->
+                            JD.succeed (0 / 0)
+
+                        ( "Infinity", _ ) ->
+                            JD.succeed (1 / 0)
+
+                        ( "-Infinity", _ ) ->
+                            JD.succeed (-1 / 0)
+
+                        ( _, Just f ) ->
+                            JD.succeed f
+
+                        _ ->
+                            JD.fail ("invalid float: " ++ s)
+                )
+        ]
+
+
+{-| Bytes are base64 strings (standard or URL safe).
+-}
+decodeBytes : JD.Decoder Bytes
+decodeBytes =
+    JD.string
+        |> JD.andThen
+            (\s ->
+                case Elmer.fromBase64 s of
+                    Just bytes ->
+                        JD.succeed bytes
+
+                    Nothing ->
+                        JD.fail ("invalid base64: " ++ s)
+            )
+
+
+{-| Maps are objects. Non-string keys are converted from their string form.
+-}
+decodeDict : (String -> Maybe comparable) -> JD.Decoder v -> JD.Decoder (Dict comparable v)
+decodeDict toKey decoder =
+    JD.keyValuePairs decoder
+        |> JD.andThen
+            (\pairs ->
+                case List.foldr (\( k, v ) acc -> Maybe.map2 (\key rest -> ( key, v ) :: rest) (toKey k) acc) (Just []) pairs of
+                    Just list ->
+                        JD.succeed (Dict.fromList list)
+
+                    Nothing ->
+                        JD.fail "invalid map key"
+            )
+
+
+{-| Maps with bool keys are association lists, see `Protobuf.Elmer.assocToDict`.
+-}
+decodeBoolKeys : JD.Decoder v -> JD.Decoder (List ( Bool, v ))
+decodeBoolKeys decoder =
+    let
+        toKey k =
+            case k of
+                "true" ->
+                    Just (Elmer.boolToKey True)
+
+                "false" ->
+                    Just (Elmer.boolToKey False)
+
+                _ ->
+                    Nothing
+    in
+    JD.map (Elmer.dictToAssoc Elmer.keyToBool) (decodeDict toKey decoder)
+
+
+{-| -}
+encodeInt64 : Int -> JE.Value
+encodeInt64 =
+    String.fromInt >> JE.string
+
+
+{-| -}
+encodeFloat : Float -> JE.Value
+encodeFloat f =
+    if isNaN f then
+        JE.string "NaN"
+
+    else if isInfinite f && f > 0 then
+        JE.string "Infinity"
+
+    else if isInfinite f then
+        JE.string "-Infinity"
+
+    else
+        JE.float f
+
+
+{-| -}
+encodeBytes : Bytes -> JE.Value
+encodeBytes =
+    Elmer.toBase64 >> JE.string
+
+
+{-| -}
+encodeDict : (comparable -> String) -> (v -> JE.Value) -> Dict comparable v -> JE.Value
+encodeDict fromKey =
+    JE.dict fromKey
+
+
+{-| -}
+encodeBoolKeys : (v -> JE.Value) -> List ( Bool, v ) -> JE.Value
+encodeBoolKeys encoder =
+    let
+        fromKey k =
+            if Elmer.keyToBool k then
+                "true"
+
+            else
+                "false"
+    in
+    Elmer.assocToDict Elmer.boolToKey >> encodeDict fromKey encoder
+
+
+
+-- Well-known type decoders
+
+
+{-| -}
+decodeBoolValue : JD.Decoder Elmer.BoolValue
+decodeBoolValue =
+    JD.nullable JD.bool
+
+
+{-| -}
+decodeBytesValue : JD.Decoder Elmer.BytesValue
+decodeBytesValue =
+    JD.nullable decodeBytes
+
+
+{-| -}
+decodeDoubleValue : JD.Decoder Elmer.DoubleValue
+decodeDoubleValue =
+    JD.nullable decodeFloat
+
+
+{-| Durations are seconds with an "s" suffix e.g., "1.5s".
+-}
+decodeDuration : JD.Decoder GP.Duration
+decodeDuration =
+    JD.string
+        |> JD.andThen
+            (\s ->
+                case parseDuration s of
+                    Just d ->
+                        JD.succeed d
+
+                    Nothing ->
+                        JD.fail ("invalid duration: " ++ s)
+            )
+
+
+{-| -}
+decodeEmpty : JD.Decoder GP.Empty
+decodeEmpty =
+    JD.succeed Elmer.emptyEmpty
+
+
+{-| Field masks are a comma separated list of lowerCamelCase paths.
+-}
+decodeFieldMask : JD.Decoder GP.FieldMask
+decodeFieldMask =
+    JD.string
+        |> JD.map (String.split "," >> List.filter ((/=) "") >> List.map camelToSnake >> GP.FieldMask)
+
+
+{-| -}
+decodeFloatValue : JD.Decoder Elmer.FloatValue
+decodeFloatValue =
+    JD.nullable decodeFloat
+
+
+{-| -}
+decodeInt32Value : JD.Decoder Elmer.Int32Value
+decodeInt32Value =
+    JD.nullable decodeInt
+
+
+{-| -}
+decodeInt64Value : JD.Decoder Elmer.Int64Value
+decodeInt64Value =
+    JD.nullable decodeInt
+
+
+{-| -}
+decodeListValue : JD.Decoder GP.ListValue
+decodeListValue =
+    JD.list (JD.lazy (\_ -> decodeValue))
+        |> JD.map (GP.ListValueValues >> GP.ListValue)
+
+
+{-| -}
+decodeNullValue : JD.Decoder GP.NullValue
+decodeNullValue =
+    JD.null GP.NullValue
+
+
+{-| -}
+decodeStringValue : JD.Decoder Elmer.StringValue
+decodeStringValue =
+    JD.nullable JD.string
+
+
+{-| -}
+decodeStruct : JD.Decoder GP.Struct
+decodeStruct =
+    JD.dict (JD.lazy (\_ -> decodeValue))
+        |> JD.map (GP.StructFields >> GP.Struct)
+
+
+{-| Timestamps are RFC 3339 strings e.g., "1972-01-01T10:00:20.021Z". Precision is limited to milliseconds.
+-}
+decodeTimestamp : JD.Decoder Time.Posix
+decodeTimestamp =
+    JD.string
+        |> JD.andThen
+            (\s ->
+                case parseTimestamp s of
+                    Just t ->
+                        JD.succeed t
+
+                    Nothing ->
+                        JD.fail ("invalid timestamp: " ++ s)
+            )
+
+
+{-| -}
+decodeUInt32Value : JD.Decoder Elmer.UInt32Value
+decodeUInt32Value =
+    JD.nullable decodeInt
+
+
+{-| -}
+decodeUInt64Value : JD.Decoder Elmer.UInt64Value
+decodeUInt64Value =
+    JD.nullable decodeInt
+
+
+{-| Values are any JSON.
+-}
+decodeValue : JD.Decoder GP.Value
+decodeValue =
+    JD.oneOf
+        [ JD.null (GP.KindNullValue GP.NullValue)
+        , JD.map GP.KindBoolValue JD.bool
+        , JD.map GP.KindNumberValue JD.float
+        , JD.map GP.KindStringValue JD.string
+        , JD.map GP.KindListValue (JD.lazy (\_ -> decodeListValue))
+        , JD.map GP.KindStructValue (JD.lazy (\_ -> decodeStruct))
+        ]
+        |> JD.map (Just >> GP.ValueKind >> GP.Value)
+
+
+
+-- Well-known type encoders
+
+
+{-| -}
+encodeBoolValue : Elmer.BoolValue -> JE.Value
+encodeBoolValue =
+    encodeNullable JE.bool
+
+
+{-| -}
+encodeBytesValue : Elmer.BytesValue -> JE.Value
+encodeBytesValue =
+    encodeNullable encodeBytes
+
+
+{-| -}
+encodeDoubleValue : Elmer.DoubleValue -> JE.Value
+encodeDoubleValue =
+    encodeNullable encodeFloat
+
+
+{-| -}
+encodeDuration : GP.Duration -> JE.Value
+encodeDuration d =
+    let
+        sign =
+            if d.seconds < 0 || d.nanos < 0 then
+                "-"
+
+            else
+                ""
+
+        nanos =
+            String.padLeft 9 '0' (String.fromInt (abs d.nanos))
+
+        fraction =
+            if d.nanos == 0 then
+                ""
+
+            else if String.endsWith "000000" nanos then
+                "." ++ String.left 3 nanos
+
+            else if String.endsWith "000" nanos then
+                "." ++ String.left 6 nanos
+
+            else
+                "." ++ nanos
+    in
+    JE.string (sign ++ String.fromInt (abs d.seconds) ++ fraction ++ "s")
+
+
+{-| -}
+encodeEmpty : GP.Empty -> JE.Value
+encodeEmpty _ =
+    JE.object []
+
+
+{-| -}
+encodeFieldMask : GP.FieldMask -> JE.Value
+encodeFieldMask mask =
+    JE.string (String.join "," (List.map snakeToCamel mask.paths))
+
+
+{-| -}
+encodeFloatValue : Elmer.FloatValue -> JE.Value
+encodeFloatValue =
+    encodeNullable encodeFloat
+
+
+{-| -}
+encodeInt32Value : Elmer.Int32Value -> JE.Value
+encodeInt32Value =
+    encodeNullable JE.int
+
+
+{-| -}
+encodeInt64Value : Elmer.Int64Value -> JE.Value
+encodeInt64Value =
+    encodeNullable encodeInt64
+
+
+{-| -}
+encodeListValue : GP.ListValue -> JE.Value
+encodeListValue list =
+    case list.values of
+        GP.ListValueValues values ->
+            JE.list encodeValue values
+
+
+{-| -}
+encodeNullValue : GP.NullValue -> JE.Value
+encodeNullValue _ =
+    JE.null
+
+
+{-| -}
+encodeStringValue : Elmer.StringValue -> JE.Value
+encodeStringValue =
+    encodeNullable JE.string
+
+
+{-| -}
+encodeStruct : GP.Struct -> JE.Value
+encodeStruct struct =
+    case struct.fields of
+        GP.StructFields fields ->
+            JE.dict identity encodeValue fields
+
+
+{-| -}
+encodeTimestamp : Time.Posix -> JE.Value
+encodeTimestamp posix =
+    let
+        pad n =
+            String.fromInt >> String.padLeft n '0'
+
+        part toPart =
+            toPart Time.utc posix
+
+        millis =
+            part Time.toMillis
+
+        fraction =
+            if millis == 0 then
+                ""
+
+            else
+                "." ++ pad 3 millis
+    in
+    JE.string <|
+        pad 4 (part Time.toYear)
+            ++ "-"
+            ++ pad 2 (monthToInt (part Time.toMonth))
+            ++ "-"
+            ++ pad 2 (part Time.toDay)
+            ++ "T"
+            ++ pad 2 (part Time.toHour)
+            ++ ":"
+            ++ pad 2 (part Time.toMinute)
+            ++ ":"
+            ++ pad 2 (part Time.toSecond)
+            ++ fraction
+            ++ "Z"
+
+
+{-| -}
+encodeUInt32Value : Elmer.UInt32Value -> JE.Value
+encodeUInt32Value =
+    encodeNullable JE.int
+
+
+{-| -}
+encodeUInt64Value : Elmer.UInt64Value -> JE.Value
+encodeUInt64Value =
+    encodeNullable encodeInt64
+
+
+{-| -}
+encodeValue : GP.Value -> JE.Value
+encodeValue value =
+    case value.kind of
+        GP.ValueKind (Just (GP.KindBoolValue b)) ->
+            JE.bool b
+
+        GP.ValueKind (Just (GP.KindNumberValue n)) ->
+            JE.float n
+
+        GP.ValueKind (Just (GP.KindStringValue s)) ->
+            JE.string s
+
+        GP.ValueKind (Just (GP.KindListValue l)) ->
+            encodeListValue l
+
+        GP.ValueKind (Just (GP.KindStructValue s)) ->
+            encodeStruct s
+
+        _ ->
+            JE.null
+
+
+encodeNullable : (a -> JE.Value) -> Maybe a -> JE.Value
+encodeNullable encoder =
+    Maybe.map encoder >> Maybe.withDefault JE.null
+
+
+
+-- Conversions
+
+
+parseDuration : String -> Maybe GP.Duration
+parseDuration str =
+    let
+        ( negative, unsigned ) =
+            if String.startsWith "-" str then
+                ( True, String.dropLeft 1 str )
+
+            else
+                ( False, str )
+
+        sign =
+            if negative then
+                -1
+
+            else
+                1
+    in
+    if String.endsWith "s" unsigned then
+        case String.split "." (String.dropRight 1 unsigned) of
+            [ seconds ] ->
+                Maybe.map (\s -> GP.Duration (sign * s) 0) (String.toInt seconds)
+
+            [ seconds, nanos ] ->
+                Maybe.map2 (\s n -> GP.Duration (sign * s) (sign * n))
+                    (String.toInt seconds)
+                    (String.toInt (String.left 9 (String.padRight 9 '0' nanos)))
+
+            _ ->
+                Nothing
+
+    else
+        Nothing
+
+
+parseTimestamp : String -> Maybe Time.Posix
+parseTimestamp str =
+    let
+        digits from to =
+            String.toInt (String.slice from to str)
+
+        days =
+            Maybe.map3 daysFromCivil (digits 0 4) (digits 5 7) (digits 8 10)
+
+        seconds =
+            Maybe.map3 (\h m s -> h * 3600 + m * 60 + s) (digits 11 13) (digits 14 16) (digits 17 19)
+
+        ( fraction, zone ) =
+            splitFraction (String.dropLeft 19 str)
+
+        millis =
+            String.toInt (String.left 3 (String.padRight 3 '0' fraction))
+
+        offset =
+            if zone == "Z" || zone == "z" then
+                Just 0
+
+            else
+                Maybe.map2 (\h m -> (h * 60 + m) * 60)
+                    (String.toInt (String.slice 1 3 zone))
+                    (String.toInt (String.slice 4 6 zone))
+                    |> Maybe.map
+                        (if String.startsWith "-" zone then
+                            negate
+
+                         else
+                            identity
+                        )
+    in
+    if String.slice 4 5 str == "-" && String.slice 10 11 (String.toUpper str) == "T" then
+        Maybe.map4 (\d s ms o -> Time.millisToPosix ((d * 86400 + s - o) * 1000 + ms)) days seconds millis offset
+
+    else
+        Nothing
+
+
+{-| Splits ".123Z" into ( "123", "Z" )
+-}
+splitFraction : String -> ( String, String )
+splitFraction rest =
+    if String.startsWith "." rest then
+        let
+            fraction =
+                String.fromList (leadingDigits (String.toList (String.dropLeft 1 rest)))
+        in
+        ( fraction, String.dropLeft (String.length fraction + 1) rest )
+
+    else
+        ( "", rest )
+
+
+leadingDigits : List Char -> List Char
+leadingDigits chars =
+    case chars of
+        c :: rest ->
+            if Char.isDigit c then
+                c :: leadingDigits rest
+
+            else
+                []
+
+        [] ->
+            []
+
+
+{-| Days since 1970-01-01. See <http://howardhinnant.github.io/date_algorithms.html>
+-}
+daysFromCivil : Int -> Int -> Int -> Int
+daysFromCivil year month day =
+    let
+        y =
+            if month <= 2 then
+                year - 1
+
+            else
+                year
+
+        era =
+            (if y >= 0 then
+                y
+
+             else
+                y - 399
+            )
+                // 400
+
+        yearOfEra =
+            y - era * 400
+
+        dayOfYear =
+            (153 * modBy 12 (month + 9) + 2) // 5 + day - 1
+
+        dayOfEra =
+            yearOfEra * 365 + yearOfEra // 4 - yearOfEra // 100 + dayOfYear
+    in
+    era * 146097 + dayOfEra - 719468
+
+
+monthToInt : Time.Month -> Int
+monthToInt month =
+    case month of
+        Time.Jan ->
+            1
+
+        Time.Feb ->
+            2
+
+        Time.Mar ->
+            3
+
+        Time.Apr ->
+            4
+
+        Time.May ->
+            5
+
+        Time.Jun ->
+            6
+
+        Time.Jul ->
+            7
+
+        Time.Aug ->
+            8
+
+        Time.Sep ->
+            9
+
+        Time.Oct ->
+            10
+
+        Time.Nov ->
+            11
+
+        Time.Dec ->
+            12
+
+
+snakeToCamel : String -> String
+snakeToCamel =
+    String.split "_"
+        >> List.indexedMap
+            (\i part ->
+                if i == 0 then
+                    part
+
+                else
+                    String.toUpper (String.left 1 part) ++ String.dropLeft 1 part
+            )
+        >> String.concat
+
+
+camelToSnake : String -> String
+camelToSnake =
+    String.toList
+        >> List.map
+            (\c ->
+                if Char.isUpper c then
+                    "_" ++ String.fromChar (Char.toLower c)
+
+                else
+                    String.fromChar c
+            )
+        >> String.concat
@@ -0,0 +1,54 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+
+module Protobuf.ElmerPages exposing (fetchAll, resolver)
+
+{-| Helper functions for `protoc-gen-elmer-twirp` codegen. Fetches all pages of list methods following [AIP-158](https://google.aip.dev/158) pagination.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Pagination
+
+@docs fetchAll, resolver
+
+-}
+
+import Http
+import Protobuf.Decode as PD
+import Protobuf.ElmerEffect exposing (resolveBytes)
+import Task exposing (Task)
+
+
+{-| Keeps fetching pages until there's no next page or the maximum number of pages has been fetched. Results from each page are concatenated.
+-}
+fetchAll : Int -> (req -> Task x resp) -> (req -> resp -> Maybe req) -> (resp -> List a) -> req -> Task x (List a)
+fetchAll maxPages fetch nextPage results req =
+    fetch req
+        |> Task.andThen
+            (\resp ->
+                case ( maxPages > 1, nextPage req resp ) of
+                    ( True, Just next ) ->
+                        fetchAll (maxPages - 1) fetch nextPage results next
+                            |> Task.map ((++) (results resp))
+
+                    _ ->
+                        Task.succeed (results resp)
+            )
+
+
+{-| Decodes a Protobuf message for use with `Http.riskyTask`.
+-}
+resolver : PD.Decoder a -> Http.Resolver Http.Error a
+resolver decoder =
+    Http.bytesResolver
+        (resolveBytes
+            >> Result.andThen (PD.decode decoder >> Result.fromMaybe (Http.BadBody "Failed to decode Protobuf message"))
+        )
@@ -0,0 +1,370 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+module Protobuf.ElmerRandom exposing
+    ( Config, defaultConfig
+    , andMap, list, maybe, oneOf, dict, assoc
+    , int32, uint32, float, float32, string, bytes
+    , randomAny, randomApi, randomBoolValue, randomBytesValue, randomDoubleValue, randomDuration, randomEmpty, randomEnum, randomEnumValue, randomField, randomFieldMask, randomField_Cardinality, randomField_Kind, randomFloatValue, randomInt32Value, randomInt64Value, randomListValue, randomMethod, randomMixin, randomNullValue, randomOption, randomSourceContext, randomStringValue, randomStruct, randomSyntax, randomTimestamp, randomUInt32Value, randomUInt64Value, randomValue, randomXType
+    )
+
+{-| Helper types and functions for `protoc-gen-elmer-random` codegen. Unlike fuzzers, these only depend on `elm/random` so can be used in application code e.g., for demo or seed data.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Configuration
+
+@docs Config, defaultConfig
+
+
+# Combinators
+
+@docs andMap, list, maybe, oneOf, dict, assoc
+
+
+# Scalars
+
+@docs int32, uint32, float, float32, string, bytes
+
+
+# Well-known types
+
+Wrappers, `Timestamp`, `Duration`, `Empty` and `FieldMask` are random. The rest are always their empty value.
+
+@docs randomAny, randomApi, randomBoolValue, randomBytesValue, randomDoubleValue, randomDuration, randomEmpty, randomEnum, randomEnumValue, randomField, randomFieldMask, randomField_Cardinality, randomField_Kind, randomFloatValue, randomInt32Value, randomInt64Value, randomListValue, randomMethod, randomMixin, randomNullValue, randomOption, randomSourceContext, randomStringValue, randomStruct, randomSyntax, randomTimestamp, randomUInt32Value, randomUInt64Value, randomValue, randomXType
+
+-}
+
+import Bytes exposing (Bytes)
+import Bytes.Encode as BE
+import Dict exposing (Dict)
+import Google.Protobuf as GP
+import Protobuf.Elmer as Elmer
+import Random exposing (Generator)
+import Time
+
+
+{-| Limits on generated data. Lists (including maps and bytes) have up to `maxList` items and strings up to `maxString` characters taken from `alphabet`.
+-}
+type alias Config =
+    { maxList : Int
+    , maxString : Int
+    , alphabet : String
+    }
+
+
+{-| Short lists and lowercase words.
+-}
+defaultConfig : Config
+defaultConfig =
+    { maxList = 3
+    , maxString = 10
+    , alphabet = "abcdefghijklmnopqrstuvwxyz"
+    }
+
+
+
+-- Combinators
+
+
+{-| Applies a generated value to a generated function. Used to build records with any number of fields.
+-}
+andMap : Generator a -> Generator (a -> b) -> Generator b
+andMap =
+    Random.map2 (|>)
+
+
+{-| Up to `maxList` items.
+-}
+list : Config -> Generator a -> Generator (List a)
+list config gen =
+    Random.int 0 config.maxList
+        |> Random.andThen (\n -> Random.list n gen)
+
+
+{-| Half the time `Nothing`.
+-}
+maybe : Generator a -> Generator (Maybe a)
+maybe gen =
+    Random.uniform False [ True ]
+        |> Random.andThen
+            (\present ->
+                if present then
+                    Random.map Just gen
+
+                else
+                    Random.constant Nothing
+            )
+
+
+{-| Picks one of the generators.
+-}
+oneOf : Generator a -> List (Generator a) -> Generator a
+oneOf first rest =
+    Random.uniform first rest
+        |> Random.andThen identity
+
+
+{-| Up to `maxList` entries. Duplicate keys keep the last value.
+-}
+dict : Config -> Generator comparable -> Generator v -> Generator (Dict comparable v)
+dict config key val =
+    list config (Random.pair key val)
+        |> Random.map Dict.fromList
+
+
+{-| An association list for keys that aren't `comparable` e.g., bools. Sorted by key without duplicates.
+-}
+assoc : Config -> (k -> comparable) -> (comparable -> k) -> Generator k -> Generator v -> Generator (List ( k, v ))
+assoc config toKey fromKey key val =
+    list config (Random.pair key val)
+        |> Random.map (Elmer.assocToDict toKey >> Elmer.dictToAssoc fromKey)
+
+
+
+-- Scalars
+
+
+{-| Small numbers look better in demos than the full range.
+-}
+int32 : Generator Int
+int32 =
+    Random.int -1000 1000
+
+
+{-| -}
+uint32 : Generator Int
+uint32 =
+    Random.int 0 1000
+
+
+{-| -}
+float : Generator Float
+float =
+    Random.float -1000 1000
+
+
+{-| Quarters, which `float` fields hold exactly.
+-}
+float32 : Generator Float
+float32 =
+    Random.int -4000 4000
+        |> Random.map (\i -> toFloat i / 4)
+
+
+{-| -}
+string : Config -> Generator String
+string config =
+    case String.toList config.alphabet of
+        [] ->
+            Random.constant ""
+
+        c :: cs ->
+            Random.int 0 config.maxString
+                |> Random.andThen (\n -> Random.list n (Random.uniform c cs))
+                |> Random.map String.fromList
+
+
+{-| -}
+bytes : Config -> Generator Bytes
+bytes config =
+    list config (Random.int 0 255)
+        |> Random.map (List.map BE.unsignedInt8 >> BE.sequence >> BE.encode)
+
+
+
+-- Well-known types
+
+
+{-| -}
+randomBoolValue : Config -> Generator Elmer.BoolValue
+randomBoolValue _ =
+    maybe (Random.uniform False [ True ])
+
+
+{-| -}
+randomBytesValue : Config -> Generator Elmer.BytesValue
+randomBytesValue config =
+    maybe (bytes config)
+
+
+{-| -}
+randomDoubleValue : Config -> Generator Elmer.DoubleValue
+randomDoubleValue _ =
+    maybe float
+
+
+{-| -}
+randomFloatValue : Config -> Generator Elmer.FloatValue
+randomFloatValue _ =
+    maybe float32
+
+
+{-| -}
+randomInt32Value : Config -> Generator Elmer.Int32Value
+randomInt32Value _ =
+    maybe int32
+
+
+{-| -}
+randomInt64Value : Config -> Generator Elmer.Int64Value
+randomInt64Value _ =
+    maybe int32
+
+
+{-| -}
+randomStringValue : Config -> Generator Elmer.StringValue
+randomStringValue config =
+    maybe (string config)
+
+
+{-| -}
+randomUInt32Value : Config -> Generator Elmer.UInt32Value
+randomUInt32Value _ =
+    maybe uint32
+
+
+{-| -}
+randomUInt64Value : Config -> Generator Elmer.UInt64Value
+randomUInt64Value _ =
+    maybe uint32
+
+
+{-| Between 2000 and 2030, to the second.
+-}
+randomTimestamp : Config -> Generator Time.Posix
+randomTimestamp _ =
+    Random.int 946684800 1893456000
+        |> Random.map (\s -> Time.millisToPosix (s * 1000))
+
+
+{-| Up to a day.
+-}
+randomDuration : Config -> Generator GP.Duration
+randomDuration _ =
+    Random.map2 GP.Duration (Random.int 0 86400) (Random.int 0 999999999)
+
+
+{-| -}
+randomEmpty : Config -> Generator GP.Empty
+randomEmpty _ =
+    Random.constant GP.Empty
+
+
+{-| -}
+randomFieldMask : Config -> Generator GP.FieldMask
+randomFieldMask config =
+    Random.map GP.FieldMask (list config (string config))
+
+
+
+-- Empty values for Google.Protobuf pass through
+
+
+{-| -}
+randomAny : Config -> Generator GP.Any
+randomAny _ =
+    Random.constant Elmer.emptyAny
+
+
+{-| -}
+randomApi : Config -> Generator GP.Api
+randomApi _ =
+    Random.constant Elmer.emptyApi
+
+
+{-| -}
+randomEnum : Config -> Generator GP.Enum
+randomEnum _ =
+    Random.constant Elmer.emptyEnum
+
+
+{-| -}
+randomEnumValue : Config -> Generator GP.EnumValue
+randomEnumValue _ =
+    Random.constant Elmer.emptyEnumValue
+
+
+{-| -}
+randomField : Config -> Generator GP.Field
+randomField _ =
+    Random.constant Elmer.emptyField
+
+
+{-| -}
+randomField_Cardinality : Config -> Generator GP.Cardinality
+randomField_Cardinality _ =
+    Random.constant Elmer.emptyField_Cardinality
+
+
+{-| -}
+randomField_Kind : Config -> Generator GP.Kind
+randomField_Kind _ =
+    Random.constant Elmer.emptyField_Kind
+
+
+{-| -}
+randomListValue : Config -> Generator GP.ListValue
+randomListValue _ =
+    Random.constant Elmer.emptyListValue
+
+
+{-| -}
+randomMethod : Config -> Generator GP.Method
+randomMethod _ =
+    Random.constant Elmer.emptyMethod
+
+
+{-| -}
+randomMixin : Config -> Generator GP.Mixin
+randomMixin _ =
+    Random.constant Elmer.emptyMixin
+
+
+{-| -}
+randomNullValue : Config -> Generator GP.NullValue
+randomNullValue _ =
+    Random.constant Elmer.emptyNullValue
+
+
+{-| -}
+randomOption : Config -> Generator GP.Option
+randomOption _ =
+    Random.constant Elmer.emptyOption
+
+
+{-| -}
+randomSourceContext : Config -> Generator GP.SourceContext
+randomSourceContext _ =
+    Random.constant Elmer.emptySourceContext
+
+
+{-| -}
+randomStruct : Config -> Generator GP.Struct
+randomStruct _ =
+    Random.constant Elmer.emptyStruct
+
+
+{-| -}
+randomSyntax : Config -> Generator GP.Syntax
+randomSyntax _ =
+    Random.constant Elmer.emptySyntax
+
+
+{-| -}
+randomValue : Config -> Generator GP.Value
+randomValue _ =
+    Random.constant Elmer.emptyValue
+
+
+{-| -}
+randomXType : Config -> Generator GP.Type
+randomXType _ =
+    Random.constant Elmer.emptyXType
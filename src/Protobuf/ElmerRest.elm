@@ -0,0 +1,94 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+module Protobuf.ElmerRest exposing
+    ( segment, segments
+    , query, queryList, queryMaybe
+    , fromBool
+    )
+
+{-| Helper functions for `protoc-gen-elmer-rest` codegen. Builds URLs from `google.api.http` path templates.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Paths
+
+@docs segment, segments
+
+
+# Query parameters
+
+@docs query, queryList, queryMaybe
+
+
+# Conversions
+
+@docs fromBool
+
+-}
+
+import Url
+import Url.Builder
+
+
+
+-- Paths
+
+
+{-| Encodes a path variable that fills a single segment e.g., `{name}`. Slashes are escaped.
+-}
+segment : String -> String
+segment =
+    Url.percentEncode
+
+
+{-| Encodes a path variable that may fill many segments e.g., `{name=shelves/*}`. Slashes are kept.
+-}
+segments : String -> String
+segments =
+    String.split "/" >> List.map Url.percentEncode >> String.join "/"
+
+
+
+-- Query parameters
+
+
+{-| -}
+query : String -> (a -> String) -> a -> List Url.Builder.QueryParameter
+query name toString value =
+    [ Url.Builder.string name (toString value) ]
+
+
+{-| Repeated fields repeat their parameter.
+-}
+queryList : String -> (a -> String) -> List a -> List Url.Builder.QueryParameter
+queryList name toString =
+    List.map (toString >> Url.Builder.string name)
+
+
+{-| Optional fields are omitted when `Nothing`.
+-}
+queryMaybe : String -> (a -> String) -> Maybe a -> List Url.Builder.QueryParameter
+queryMaybe name toString =
+    Maybe.map (query name toString) >> Maybe.withDefault []
+
+
+
+-- Conversions
+
+
+{-| -}
+fromBool : Bool -> String
+fromBool b =
+    if b then
+        "true"
+
+    else
+        "false"
@@ -0,0 +1,101 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+module Protobuf.ElmerStreams exposing
+    ( Frame, Event(..)
+    , open, send, close, toEvent
+    )
+
+{-| Helper types and functions for `protoc-gen-elmer-streams` codegen. Streaming RPC methods are sent over ports to a WebSocket. See `js/elmer-streams.js` for the JavaScript side.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Types
+
+@docs Frame, Event
+
+
+# Frames
+
+@docs open, send, close, toEvent
+
+-}
+
+import Protobuf.Decode as PD
+import Protobuf.Elmer exposing (fromBase64, toBase64)
+import Protobuf.Encode as PE
+
+
+
+-- Types
+
+
+{-| What is sent over a port. The handle identifies a stream (WebSocket) and is chosen by the caller. Events are "open", "send", "close" going out and "open", "message", "close", "error" coming in. Data depends on the event: a URL to open, a base64 encoded Protobuf message or an error description.
+-}
+type alias Frame =
+    { handle : String
+    , event : String
+    , data : String
+    }
+
+
+{-| Stream events received from a port. Each event holds the stream's handle.
+-}
+type Event out
+    = Opened String
+    | Received String out
+    | Closed String
+    | Failed String String
+
+
+
+-- Frames
+
+
+{-| -}
+open : String -> String -> Frame
+open url handle =
+    Frame handle "open" url
+
+
+{-| -}
+send : (a -> PE.Encoder) -> String -> a -> Frame
+send encoder handle data =
+    Frame handle "send" (encoder data |> PE.encode |> toBase64)
+
+
+{-| -}
+close : String -> Frame
+close handle =
+    Frame handle "close" ""
+
+
+{-| Converts an incoming frame to an event. Messages that can't be decoded become a `Failed` event.
+-}
+toEvent : PD.Decoder out -> Frame -> Event out
+toEvent decoder frame =
+    case frame.event of
+        "open" ->
+            Opened frame.handle
+
+        "message" ->
+            case fromBase64 frame.data |> Maybe.andThen (PD.decode decoder) of
+                Just out ->
+                    Received frame.handle out
+
+                Nothing ->
+                    Failed frame.handle "unable to decode message"
+
+        "close" ->
+            Closed frame.handle
+
+        _ ->
+            Failed frame.handle frame.data
+
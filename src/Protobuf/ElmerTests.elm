@@ -8,7 +8,7 @@
 
 
 module Protobuf.ElmerTests exposing
-    ( runTest
+    ( runTest, mergeTest, diffTest, conformance, conformanceJson
     , fuzzAny, fuzzApi, fuzzBoolValue, fuzzBytes, fuzzBytesValue, fuzzDoubleValue, fuzzDuration, fuzzEmpty, fuzzEnum, fuzzEnumValue, fuzzField, fuzzFieldMask, fuzzField_Cardinality, fuzzField_Kind, fuzzFloat32, fuzzFloatValue, fuzzInt32, fuzzInt32Value, fuzzInt64Value, fuzzListValue, fuzzMethod, fuzzMinInt32, fuzzMixin, fuzzNullValue, fuzzOption, fuzzPosInt32, fuzzSourceContext, fuzzStringValue, fuzzStruct, fuzzSyntax, fuzzTimestamp, fuzzUInt32, fuzzUInt32Value, fuzzUInt64Value, fuzzValue, fuzzXType
     )
 
@@ -19,7 +19,7 @@ See the project on how this may be used: <https://github.com/feral-dot-io/protoc
 
 # Test runners
 
-@docs runTest
+@docs runTest, mergeTest, diffTest, conformance, conformanceJson
 
 
 # Fuzzers
@@ -34,9 +34,12 @@ import Dict
 import Expect
 import Fuzz exposing (Fuzzer)
 import Google.Protobuf as GP
+import Json.Decode as JD
+import Json.Encode as JE
 import Protobuf.Decode as PD
 import Protobuf.Elmer as Elmer
 import Protobuf.Encode as PE
+import Test exposing (Test, describe, test)
 import Time
 
 
@@ -49,6 +52,66 @@ runTest dec enc data =
         |> Expect.equal (Just data)
 
 
+{-| Checks that merging two values matches decoding their concatenated encodings, as Protobuf defines merging. Compares with the generated equality since Bytes can't use `==`.
+-}
+mergeTest : PD.Decoder data -> (data -> PE.Encoder) -> (data -> data -> data) -> (data -> data -> Bool) -> ( data, data ) -> Expect.Expectation
+mergeTest dec enc merge equal ( a, b ) =
+    BE.encode (BE.sequence [ BE.bytes (PE.encode (enc a)), BE.bytes (PE.encode (enc b)) ])
+        |> PD.decode dec
+        |> Maybe.map (equal (merge a b))
+        |> Expect.equal (Just True)
+
+
+{-| Checks that patching the old value with the diff reproduces the new value, and that equal values have no diff.
+-}
+diffTest : (data -> data -> List String) -> (List String -> data -> data -> data) -> (data -> data -> Bool) -> ( data, data ) -> Expect.Expectation
+diffTest diff patch equal ( old, new ) =
+    Expect.all
+        [ \_ -> patch (diff old new) new old |> equal new |> Expect.equal True
+        , \_ -> diff new new |> Expect.equal []
+        ]
+        ()
+
+
+{-| Checks a message against a wire encoding (base64) produced by another Protobuf implementation. The wire must decode to the expected data and the data must encode to the same wire. Used by `protoc-gen-elmer-conformance`.
+-}
+conformance : String -> (data -> data -> Bool) -> PD.Decoder data -> (data -> PE.Encoder) -> String -> data -> Test
+conformance name equal dec enc wire expected =
+    describe name
+        [ test "decodes" <|
+            \_ ->
+                Elmer.fromBase64 wire
+                    |> Maybe.andThen (PD.decode dec)
+                    |> Maybe.map (equal expected)
+                    |> Expect.equal (Just True)
+        , test "encodes" <|
+            \_ ->
+                PE.encode (enc expected)
+                    |> Elmer.toBase64
+                    |> Expect.equal wire
+        ]
+
+
+{-| Checks a message against JSON produced by another Protobuf implementation. The JSON must decode to the expected data and the data must survive our JSON encoder. JSON is only compared after decoding since field order, spacing and defaults may differ. Used by `protoc-gen-elmer-conformance` with `json=t`.
+-}
+conformanceJson : String -> (data -> data -> Bool) -> JD.Decoder data -> (data -> JE.Value) -> String -> data -> Test
+conformanceJson name equal dec enc json expected =
+    describe name
+        [ test "decodes JSON" <|
+            \_ ->
+                JD.decodeString dec json
+                    |> Result.mapError JD.errorToString
+                    |> Result.map (equal expected)
+                    |> Expect.equal (Ok True)
+        , test "encodes JSON" <|
+            \_ ->
+                JD.decodeValue dec (enc expected)
+                    |> Result.mapError JD.errorToString
+                    |> Result.map (equal expected)
+                    |> Expect.equal (Ok True)
+        ]
+
+
 
 -- Protobuf-specific fuzzers
 
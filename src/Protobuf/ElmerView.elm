@@ -0,0 +1,440 @@
+-- This file is part of protoc-gen-elmer.
+--
+-- Protoc-gen-elmer is free software: you can redistribute it and/or modify it under the terms of the GNU Lesser General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+--
+-- Protoc-gen-elmer is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more details.
+--
+-- You should have received a copy of the GNU Lesser General Public License along with Protoc-gen-elmer. If not, see <https:--www.gnu.org/licenses/>.
+
+
+
+module Protobuf.ElmerView exposing
+    ( record, field, oneof
+    , list, maybe, dict
+    , bool, int, float, string, bytes, enum, encoded
+    , viewAny, viewApi, viewBoolValue, viewBytesValue, viewDoubleValue, viewDuration, viewEmpty, viewEnum, viewEnumValue, viewField, viewFieldMask, viewField_Cardinality, viewField_Kind, viewFloatValue, viewInt32Value, viewInt64Value, viewListValue, viewMethod, viewMixin, viewNullValue, viewOption, viewSourceContext, viewStringValue, viewStruct, viewSyntax, viewTimestamp, viewUInt32Value, viewUInt64Value, viewValue, viewXType
+    )
+
+{-| Helper functions for `protoc-gen-elmer-view` codegen. Renders any message as a tree of `<details>` elements so it can be collapsed without any application state e.g., for admin or debug panels.
+
+See the project on how this may be used: <https://github.com/feral-dot-io/protoc-gen-elmer>
+
+
+# Messages
+
+@docs record, field, oneof
+
+
+# Containers
+
+@docs list, maybe, dict
+
+
+# Scalars
+
+@docs bool, int, float, string, bytes, enum, encoded
+
+
+# Well-known types
+
+Wrappers and types with a JSON mapping (e.g., `Timestamp`) are shown as values. The rest are shown as their encoded bytes.
+
+@docs viewAny, viewApi, viewBoolValue, viewBytesValue, viewDoubleValue, viewDuration, viewEmpty, viewEnum, viewEnumValue, viewField, viewFieldMask, viewField_Cardinality, viewField_Kind, viewFloatValue, viewInt32Value, viewInt64Value, viewListValue, viewMethod, viewMixin, viewNullValue, viewOption, viewSourceContext, viewStringValue, viewStruct, viewSyntax, viewTimestamp, viewUInt32Value, viewUInt64Value, viewValue, viewXType
+
+-}
+
+import Bytes exposing (Bytes)
+import Bytes.Decode as BD
+import Google.Protobuf as GP
+import Html exposing (Html)
+import Html.Attributes as HA
+import Json.Encode as JE
+import Protobuf.Elmer as Elmer
+import Protobuf.ElmerJson as ElmerJson
+import Protobuf.Encode as PE
+import Time
+
+
+
+-- Messages
+
+
+{-| A message labelled by its full Protobuf name e.g., `my.pkg.Foo`. Starts open.
+-}
+record : String -> List (Html msg) -> Html msg
+record name fields =
+    Html.details [ HA.attribute "open" "", HA.style "font-family" "monospace" ]
+        [ Html.summary [] [ Html.text name ]
+        , children fields
+        ]
+
+
+{-| A message field labelled with its Protobuf name and number.
+-}
+field : String -> Int -> Html msg -> Html msg
+field name number value =
+    Html.li [] [ label (name ++ " (" ++ String.fromInt number ++ ")"), value ]
+
+
+{-| A oneof. Set fields are shown by the given view, which should use `field`.
+-}
+oneof : String -> (a -> Html msg) -> Maybe a -> Html msg
+oneof name view value =
+    case value of
+        Just v ->
+            view v
+
+        Nothing ->
+            Html.li [] [ label name, unset ]
+
+
+children : List (Html msg) -> Html msg
+children =
+    Html.ul
+        [ HA.style "list-style" "none"
+        , HA.style "margin" "0"
+        , HA.style "padding-left" "1.5em"
+        ]
+
+
+label : String -> Html msg
+label name =
+    Html.span [ HA.style "color" "gray" ] [ Html.text (name ++ ": ") ]
+
+
+unset : Html msg
+unset =
+    Html.i [] [ Html.text "not set" ]
+
+
+
+-- Containers
+
+
+{-| Items labelled by their index. Starts open.
+-}
+list : (a -> Html msg) -> List a -> Html msg
+list view items =
+    collapsible (String.fromInt (List.length items) ++ " items")
+        (List.indexedMap
+            (\i v -> Html.li [] [ label ("[" ++ String.fromInt i ++ "]"), view v ])
+            items
+        )
+
+
+{-| -}
+maybe : (a -> Html msg) -> Maybe a -> Html msg
+maybe view value =
+    Maybe.map view value
+        |> Maybe.withDefault unset
+
+
+{-| Map entries. Use `Dict.toList` for dictionaries.
+-}
+dict : (k -> Html msg) -> (v -> Html msg) -> List ( k, v ) -> Html msg
+dict viewKey viewVal entries =
+    collapsible (String.fromInt (List.length entries) ++ " entries")
+        (List.map
+            (\( k, v ) -> Html.li [] [ viewKey k, Html.text " → ", viewVal v ])
+            entries
+        )
+
+
+collapsible : String -> List (Html msg) -> Html msg
+collapsible summary items =
+    if List.isEmpty items then
+        Html.i [] [ Html.text "empty" ]
+
+    else
+        Html.details [ HA.attribute "open" "" ]
+            [ Html.summary [] [ Html.text summary ]
+            , children items
+            ]
+
+
+
+-- Scalars
+
+
+scalar : String -> Html msg
+scalar =
+    Html.text
+
+
+{-| -}
+bool : Bool -> Html msg
+bool b =
+    if b then
+        scalar "true"
+
+    else
+        scalar "false"
+
+
+{-| -}
+int : Int -> Html msg
+int =
+    String.fromInt >> scalar
+
+
+{-| -}
+float : Float -> Html msg
+float =
+    String.fromFloat >> scalar
+
+
+{-| Quoted and escaped.
+-}
+string : String -> Html msg
+string s =
+    scalar (JE.encode 0 (JE.string s))
+
+
+{-| Hex with the length e.g., `2 bytes: 0a ff`.
+-}
+bytes : Bytes -> Html msg
+bytes b =
+    let
+        width =
+            Bytes.width b
+
+        hex =
+            BD.decode (BD.loop ( width, [] ) hexStep) b
+                |> Maybe.withDefault []
+    in
+    if width == 0 then
+        scalar "0 bytes"
+
+    else
+        scalar (String.fromInt width ++ " bytes: " ++ String.join " " hex)
+
+
+hexStep : ( Int, List String ) -> BD.Decoder (BD.Step ( Int, List String ) (List String))
+hexStep ( n, acc ) =
+    if n <= 0 then
+        BD.succeed (BD.Done (List.reverse acc))
+
+    else
+        BD.map (\byte -> BD.Loop ( n - 1, hexByte byte :: acc )) BD.unsignedInt8
+
+
+hexByte : Int -> String
+hexByte byte =
+    hexDigit (byte // 16) ++ hexDigit (modBy 16 byte)
+
+
+hexDigit : Int -> String
+hexDigit i =
+    String.slice i (i + 1) "0123456789abcdef"
+
+
+{-| An enum by its Protobuf label and number e.g., `RED = 1`.
+-}
+enum : String -> Int -> Html msg
+enum name number =
+    scalar (name ++ " = " ++ String.fromInt number)
+
+
+{-| Shows a value by its encoded bytes. Used for types without a better view.
+-}
+encoded : (a -> PE.Encoder) -> a -> Html msg
+encoded encoder =
+    encoder >> PE.encode >> bytes
+
+
+json : (a -> JE.Value) -> a -> Html msg
+json encoder v =
+    scalar (JE.encode 0 (encoder v))
+
+
+
+-- Well-known types
+
+
+{-| -}
+viewBoolValue : Elmer.BoolValue -> Html msg
+viewBoolValue =
+    maybe bool
+
+
+{-| -}
+viewBytesValue : Elmer.BytesValue -> Html msg
+viewBytesValue =
+    maybe bytes
+
+
+{-| -}
+viewDoubleValue : Elmer.DoubleValue -> Html msg
+viewDoubleValue =
+    maybe float
+
+
+{-| -}
+viewFloatValue : Elmer.FloatValue -> Html msg
+viewFloatValue =
+    maybe float
+
+
+{-| -}
+viewInt32Value : Elmer.Int32Value -> Html msg
+viewInt32Value =
+    maybe int
+
+
+{-| -}
+viewInt64Value : Elmer.Int64Value -> Html msg
+viewInt64Value =
+    maybe int
+
+
+{-| -}
+viewStringValue : Elmer.StringValue -> Html msg
+viewStringValue =
+    maybe string
+
+
+{-| -}
+viewUInt32Value : Elmer.UInt32Value -> Html msg
+viewUInt32Value =
+    maybe int
+
+
+{-| -}
+viewUInt64Value : Elmer.UInt64Value -> Html msg
+viewUInt64Value =
+    maybe int
+
+
+{-| RFC 3339 in UTC.
+-}
+viewTimestamp : Time.Posix -> Html msg
+viewTimestamp =
+    json ElmerJson.encodeTimestamp
+
+
+{-| -}
+viewDuration : GP.Duration -> Html msg
+viewDuration =
+    json ElmerJson.encodeDuration
+
+
+{-| -}
+viewEmpty : GP.Empty -> Html msg
+viewEmpty _ =
+    record "google.protobuf.Empty" []
+
+
+{-| -}
+viewFieldMask : GP.FieldMask -> Html msg
+viewFieldMask =
+    json ElmerJson.encodeFieldMask
+
+
+{-| -}
+viewListValue : GP.ListValue -> Html msg
+viewListValue =
+    json ElmerJson.encodeListValue
+
+
+{-| -}
+viewNullValue : GP.NullValue -> Html msg
+viewNullValue =
+    json ElmerJson.encodeNullValue
+
+
+{-| -}
+viewStruct : GP.Struct -> Html msg
+viewStruct =
+    json ElmerJson.encodeStruct
+
+
+{-| -}
+viewValue : GP.Value -> Html msg
+viewValue =
+    json ElmerJson.encodeValue
+
+
+{-| -}
+viewAny : GP.Any -> Html msg
+viewAny v =
+    record "google.protobuf.Any"
+        [ field "type_url" 1 (string v.typeUrl)
+        , field "value" 2 (bytes v.value)
+        ]
+
+
+{-| -}
+viewSourceContext : GP.SourceContext -> Html msg
+viewSourceContext v =
+    record "google.protobuf.SourceContext"
+        [ field "file_name" 1 (string v.fileName) ]
+
+
+
+-- Encoded bytes for Google.Protobuf pass through
+
+
+{-| -}
+viewApi : GP.Api -> Html msg
+viewApi =
+    encoded GP.toApiEncoder
+
+
+{-| -}
+viewEnum : GP.Enum -> Html msg
+viewEnum =
+    encoded GP.toEnumEncoder
+
+
+{-| -}
+viewEnumValue : GP.EnumValue -> Html msg
+viewEnumValue =
+    encoded GP.toEnumValueEncoder
+
+
+{-| -}
+viewField : GP.Field -> Html msg
+viewField =
+    encoded GP.toFieldEncoder
+
+
+{-| -}
+viewField_Cardinality : GP.Cardinality -> Html msg
+viewField_Cardinality =
+    encoded GP.toCardinalityEncoder
+
+
+{-| -}
+viewField_Kind : GP.Kind -> Html msg
+viewField_Kind =
+    encoded GP.toKindEncoder
+
+
+{-| -}
+viewMethod : GP.Method -> Html msg
+viewMethod =
+    encoded GP.toMethodEncoder
+
+
+{-| -}
+viewMixin : GP.Mixin -> Html msg
+viewMixin =
+    encoded GP.toMixinEncoder
+
+
+{-| -}
+viewOption : GP.Option -> Html msg
+viewOption =
+    encoded GP.toOptionEncoder
+
+
+{-| -}
+viewSyntax : GP.Syntax -> Html msg
+viewSyntax =
+    encoded GP.toSyntaxEncoder
+
+
+{-| -}
+viewXType : GP.Type -> Html msg
+viewXType =
+    encoded GP.toTypeEncoder